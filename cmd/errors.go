@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// messageEntry maps an error or log message template to the chunks producing it
+type messageEntry struct {
+	Template  string   `json:"template"`
+	Chunks    []string `json:"chunks"`
+	Locations []string `json:"locations"`
+}
+
+// messageIndex is the output of the errors command
+type messageIndex struct {
+	Messages []messageEntry `json:"messages"`
+}
+
+// newErrorsCommand creates the command indexing error and log messages
+func newErrorsCommand(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "errors [log line]",
+		Short: "Index error and log messages by the chunks producing them",
+		Long: `Errors chunks the directory and maps the templates of the error and log messages
+found in Go code, with a NUL character (\u0000 in JSON) standing for formatted values,
+to the chunks producing them. Given a log line, only the templates matching it are
+listed, most specific first.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runErrors(opts, args)
+		},
+	}
+}
+
+// runErrors writes the message index, or the entries matching a log line
+func runErrors(opts *rootOptions, args []string) error {
+	result, err := chunkDirectory(opts, "json")
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]*messageEntry)
+	for _, chunk := range result.Chunks {
+		for _, message := range chunk.Messages {
+			entry, ok := entries[message.Template]
+			if !ok {
+				entry = &messageEntry{Template: message.Template}
+				entries[message.Template] = entry
+			}
+			entry.Chunks = append(entry.Chunks, chunk.ID)
+			for _, line := range message.Lines {
+				entry.Locations = append(entry.Locations, fmt.Sprintf("%s:%d", chunk.FilePath, line))
+			}
+		}
+	}
+
+	var index messageIndex
+	for _, entry := range entries {
+		if len(args) == 0 || messageMatches(entry.Template, args[0]) {
+			index.Messages = append(index.Messages, *entry)
+		}
+	}
+
+	// Templates with the most literal text identify a line best
+	sort.Slice(index.Messages, func(i, j int) bool {
+		a, b := index.Messages[i].Template, index.Messages[j].Template
+		if len(args) > 0 {
+			if literalA, literalB := len(strings.ReplaceAll(a, model.MessageWildcard, "")), len(strings.ReplaceAll(b, model.MessageWildcard, "")); literalA != literalB {
+				return literalA > literalB
+			}
+		}
+		return a < b
+	})
+
+	var w io.Writer = os.Stdout
+	if opts.Output != "-" {
+		file, err := os.Create(opts.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(index)
+}
+
+// messageMatches reports whether a log line contains a message template, with
+// each wildcard matching any text
+func messageMatches(template string, line string) bool {
+	parts := strings.Split(template, model.MessageWildcard)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	matched, err := regexp.MatchString(strings.Join(parts, ".*"), line)
+	return err == nil && matched
+}
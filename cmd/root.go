@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -24,6 +26,18 @@ type rootOptions struct {
 	Format       string
 	MinChunkSize int
 	MaxChunkSize int
+
+	// MaxChunkTokens is the token budget for prose documents
+	MaxChunkTokens int
+
+	// XMLSplitPaths are the element paths the XML chunker splits on
+	XMLSplitPaths []string
+
+	// TypeCheck resolves Go references with go/types instead of by name
+	TypeCheck bool
+
+	// Generated is the policy for generated Go files: keep, skip or summarize
+	Generated string
 }
 
 // NewRootCommand creates the root command for the application
@@ -42,14 +56,22 @@ and frameworks, respects .gitignore rules, and provides rich metadata for vector
 		},
 	}
 
-	// Add flags
-	cmd.Flags().StringVarP(&opts.Dir, "dir", "d", ".", "Directory to process")
-	cmd.Flags().StringVarP(&opts.Output, "output", "o", "-", "Output file (- for stdout)")
+	// Add flags, shared with subcommands except for the output format
+	cmd.PersistentFlags().StringVarP(&opts.Dir, "dir", "d", ".", "Directory to process")
+	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "-", "Output file (- for stdout)")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "vector-ready", "Output format (vector-ready, json, or jsonl)")
-	cmd.Flags().IntVarP(&opts.MinChunkSize, "min-chunk-size", "m", 10, "Minimum chunk size in lines")
-	cmd.Flags().IntVarP(&opts.MaxChunkSize, "max-chunk-size", "M", 50, "Maximum chunk size in lines")
+	cmd.PersistentFlags().IntVarP(&opts.MinChunkSize, "min-chunk-size", "m", 10, "Minimum chunk size in lines")
+	cmd.PersistentFlags().IntVarP(&opts.MaxChunkSize, "max-chunk-size", "M", 50, "Maximum chunk size in lines")
+	cmd.PersistentFlags().IntVar(&opts.MaxChunkTokens, "max-chunk-tokens", 512, "Maximum chunk size in tokens for prose documents")
+	cmd.PersistentFlags().BoolVar(&opts.TypeCheck, "type-check", false, "Resolve Go references by type-checking each package (slower, but precise)")
+	cmd.PersistentFlags().StringVar(&opts.Generated, "generated", chunker.GeneratedKeep, "Policy for generated Go files (keep, skip, or summarize)")
+	cmd.PersistentFlags().StringSliceVar(&opts.XMLSplitPaths, "xml-split-paths", chunker.DefaultXMLSplitPaths, "XML element paths to split into their own chunks")
+
+	// Add subcommands
+	cmd.AddCommand(newErrorsCommand(opts))
 
 	// Bind flags to viper
+	viper.BindPFlags(cmd.PersistentFlags())
 	viper.BindPFlags(cmd.Flags())
 
 	return cmd
@@ -57,44 +79,76 @@ and frameworks, respects .gitignore rules, and provides rich metadata for vector
 
 // runChunk executes the main functionality
 func runChunk(opts *rootOptions) error {
+	// Initialize formatter registry
+	formatterRegistry := output.NewFormatterRegistry()
+	formatterRegistry.Register("json", output.NewJSONFormatter(true))
+	formatterRegistry.Register("jsonl", output.NewJSONLinesFormatter())
+	formatterRegistry.Register("vector-ready", output.NewJSONFormatter(true))
+
+	result, err := chunkDirectory(opts, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	// Output the result
+	return outputResult(result, opts.Output, opts.Format, formatterRegistry)
+}
+
+// chunkDirectory chunks the directory given in the options. Related chunks
+// are only computed for the vector-ready format
+func chunkDirectory(opts *rootOptions, format string) (model.ChunkResult, error) {
+	if !slices.Contains(chunker.GeneratedPolicies, opts.Generated) {
+		return model.ChunkResult{}, fmt.Errorf("unsupported generated file policy: %s (use %s)", opts.Generated, strings.Join(chunker.GeneratedPolicies, ", "))
+	}
+
 	// Initialize components
 	langDetector := detector.NewDefaultLanguageDetector()
 	frameworkDetector := detector.NewDefaultFrameworkDetector()
 
 	// Initialize chunker registry
+	goChunker := chunker.NewGoChunker()
+	goChunker.TypeCheck = opts.TypeCheck
+	goChunker.Generated = opts.Generated
+
 	chunkerRegistry := chunker.NewChunkerRegistry()
-	chunkerRegistry.Register(chunker.NewGoChunker())
+	chunkerRegistry.Register(goChunker)
 	chunkerRegistry.Register(chunker.NewShellChunker())
 	chunkerRegistry.Register(chunker.NewDockerfileChunker())
+	chunkerRegistry.Register(chunker.NewNotebookChunker())
+	chunkerRegistry.Register(chunker.NewStarlarkChunker())
+	chunkerRegistry.Register(chunker.NewCMakeChunker())
+	chunkerRegistry.Register(chunker.NewXMLChunker(opts.XMLSplitPaths))
+	chunkerRegistry.Register(chunker.NewConfigChunker())
+	chunkerRegistry.Register(chunker.NewProseChunker())
+	chunkerRegistry.Register(chunker.NewTemplateChunker())
+	chunkerRegistry.Register(chunker.NewComposeChunker())
 	chunkerRegistry.Register(chunker.NewGenericChunker()) // Fallback chunker for unknown types
 
-	// Initialize formatter registry
-	formatterRegistry := output.NewFormatterRegistry()
-	formatterRegistry.Register("json", output.NewJSONFormatter(true))
-	formatterRegistry.Register("jsonl", output.NewJSONLinesFormatter())
-	formatterRegistry.Register("vector-ready", output.NewJSONFormatter(true))
-
 	// Get absolute path for the root directory
 	rootDir, err := filepath.Abs(opts.Dir)
 	if err != nil {
-		return err
+		return model.ChunkResult{}, err
 	}
 
+	langDetector.RootDir = rootDir
+	frameworkDetector.RootDir = rootDir
+
 	// Initialize GitIgnore manager
 	ignoreManager := gitignore.NewManager(rootDir)
 	if err := ignoreManager.LoadIgnores(); err != nil {
-		return err
+		return model.ChunkResult{}, err
 	}
 
 	// Process the directory
-	result, err := processDirectory(rootDir, opts.MinChunkSize, opts.MaxChunkSize,
-		opts.Format, langDetector, frameworkDetector, chunkerRegistry, ignoreManager)
-	if err != nil {
-		return err
+	options := chunker.ChunkingOptions{
+		MinChunkSize:   opts.MinChunkSize,
+		MaxChunkSize:   opts.MaxChunkSize,
+		MaxChunkTokens: opts.MaxChunkTokens,
+		RootDir:        rootDir,
+		Ignored:        ignoreManager.IsIgnored,
 	}
-
-	// Output the result
-	return outputResult(result, opts.Output, opts.Format, formatterRegistry)
+	return processDirectory(rootDir, options, format,
+		langDetector, frameworkDetector, chunkerRegistry, ignoreManager)
 }
 
 // outputResult writes the result to the specified output path
@@ -132,8 +186,7 @@ func outputResult(
 
 func processDirectory(
 	rootDir string,
-	minChunkSize int,
-	maxChunkSize int,
+	options chunker.ChunkingOptions,
 	format string,
 	langDetector detector.LanguageDetector,
 	frameworkDetector detector.FrameworkDetector,
@@ -208,11 +261,6 @@ func processDirectory(
 		}
 
 		// Process the file
-		options := chunker.ChunkingOptions{
-			MinChunkSize: minChunkSize,
-			MaxChunkSize: maxChunkSize,
-		}
-
 		fileChunks, err := fileChunker.Chunk(path, content, symbolTable, options)
 		if err != nil {
 			return fmt.Errorf("error processing %s: %v", path, err)
@@ -230,6 +278,13 @@ func processDirectory(
 		return result, err
 	}
 
+	result.Diagnostics = symbolTable.Diagnostics
+
+	// Link callers and callees
+	for i, chunk := range result.Chunks {
+		result.Chunks[i].Calls, result.Chunks[i].CalledBy = symbolTable.CallGraph(chunk.ID)
+	}
+
 	// Second pass: Only for vector-ready format, add related chunks
 	if format == "vector-ready" {
 		for i, chunk := range result.Chunks {
@@ -10,6 +10,19 @@ import (
 type ChunkingOptions struct {
 	MinChunkSize int
 	MaxChunkSize int
+
+	// MaxChunkTokens is the size budget, in estimated tokens, for chunkers of
+	// prose that has no meaningful line structure
+	MaxChunkTokens int
+
+	// RootDir is the root of the tree being processed, used by chunkers
+	// whose symbols depend on a file's location (e.g. Bazel labels)
+	RootDir string
+
+	// Ignored reports whether a file of the tree is left out of processing
+	// (e.g. by .gitignore), for chunkers that read a file's siblings. Nil
+	// means nothing is ignored
+	Ignored func(path string) bool
 }
 
 // Chunker interface defines the contract for code chunkers
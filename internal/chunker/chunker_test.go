@@ -3,9 +3,15 @@
 package chunker_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stream-ai/chunk/internal/chunker"
 	"github.com/stream-ai/chunk/internal/detector"
@@ -181,6 +187,207 @@ goodbye "$name"
 	}
 }
 
+// TestShellChunkerBoundaries tests that shell functions end at their own
+// closing brace, whatever their body holds
+func TestShellChunkerBoundaries(t *testing.T) {
+	content := `#!/bin/bash
+set -e
+
+# Prints the usage
+usage() {
+  cat <<EOF
+Usage: deploy [start|stop]
+}
+EOF
+}
+
+function deploy
+{
+  { echo "deploying"; echo "}"; } > log
+  case "$1" in
+    start) run ;;
+    stop|halt) x=$(case "$2" in now) echo 0 ;; esac) ;;
+  esac
+  echo done
+}
+
+one() { echo one; }
+deploy "$@"
+`
+
+	chunks, err := chunker.NewShellChunker().Chunk("deploy.sh", []byte(content), model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	type span struct {
+		symbols            string
+		startLine, endLine int
+	}
+	var got []span
+	for _, chunk := range chunks {
+		got = append(got, span{strings.Join(chunk.Symbols, ","), chunk.StartLine, chunk.EndLine})
+	}
+	expected := []span{
+		{"", 1, 2},
+		{"usage", 4, 10},
+		{"deploy", 12, 20},
+		{"one", 22, 22},
+		{"", 23, 23},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected chunks %v, got %v", expected, got)
+	}
+
+	// Long functions are split between the commands of their body
+	symbolTable := model.NewSymbolTable()
+	chunks, err = chunker.NewShellChunker().Chunk("deploy.sh", []byte(content), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 5})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	var parts []model.Chunk
+	for _, chunk := range chunks {
+		if len(chunk.Symbols) > 0 && strings.HasPrefix(chunk.Symbols[0], "deploy") {
+			parts = append(parts, chunk)
+		}
+	}
+	if len(parts) != 3 {
+		t.Fatalf("Expected deploy to be split in 3 parts, got %d", len(parts))
+	}
+	if parts[1].Symbols[0] != "deploy#part2" || parts[1].ParentID != parts[0].ID {
+		t.Errorf("Expected the second part to be deploy#part2 linked to the first, got %v", parts[1].Symbols)
+	}
+	if parts[1].StartLine != 15 || parts[1].EndLine != 18 {
+		t.Errorf("Expected the case statement to stay whole in lines 15-18, got %d-%d", parts[1].StartLine, parts[1].EndLine)
+	}
+	if !strings.HasPrefix(parts[2].Content, "function deploy\n{\n  echo done") {
+		t.Errorf("Expected later parts to repeat the function's opening lines, got %q", parts[2].Content)
+	}
+	if len(symbolTable.Definitions["deploy#part3"]) != 1 {
+		t.Error("Expected deploy#part3 to be defined")
+	}
+}
+
+// TestShellChunkerRelations tests that scripts relate to the scripts they
+// source, the functions they call and the variables they use
+func TestShellChunkerRelations(t *testing.T) {
+	library := `#!/bin/bash
+export API_URL="https://api.example.com"
+LOG_LEVEL=info
+retries=3
+
+setup_env() {
+  local region=eu
+  declare -g DEPLOY_REGION="$region"
+}
+`
+	script := `#!/bin/bash
+source "$(dirname "$0")/lib/common.sh"
+
+deploy() {
+  setup_env
+  curl "$API_URL/deploy?region=${DEPLOY_REGION}"
+}
+
+deploy
+`
+
+	symbolTable := model.NewSymbolTable()
+	shellChunker := chunker.NewShellChunker()
+	var scriptChunks []model.Chunk
+	for _, file := range []struct {
+		path    string
+		content string
+	}{
+		{"scripts/lib/common.sh", library},
+		{"scripts/deploy.sh", script},
+	} {
+		chunks, err := shellChunker.Chunk(file.path, []byte(file.content), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+		}
+		if file.path == "scripts/deploy.sh" {
+			scriptChunks = chunks
+		}
+	}
+
+	for _, chunk := range scriptChunks {
+		if !reflect.DeepEqual(chunk.Imports, []string{"scripts/lib/common.sh"}) {
+			t.Errorf("Expected chunk %v to import scripts/lib/common.sh, got %v", chunk.Symbols, chunk.Imports)
+		}
+	}
+
+	for symbol, expected := range map[string]bool{
+		"env:API_URL":       true,
+		"env:LOG_LEVEL":     true,
+		"env:DEPLOY_REGION": true,
+		"env:retries":       false,
+		"env:region":        false,
+	} {
+		if defined := len(symbolTable.Definitions[symbol]) > 0; defined != expected {
+			t.Errorf("Expected %s to be defined: %v, got %v", symbol, expected, defined)
+		}
+	}
+
+	relatedFiles := func(chunk model.Chunk) map[string]int {
+		files := make(map[string]int)
+		for _, id := range symbolTable.FindRelatedChunks(chunk) {
+			if related, ok := symbolTable.GetChunk(id); ok {
+				files[related.FilePath]++
+			}
+		}
+		return files
+	}
+
+	// The chunk sourcing the library relates to all of it, and the function
+	// to the chunks defining what it calls and uses
+	for _, chunk := range scriptChunks {
+		switch {
+		case chunk.StartLine == 1:
+			if relatedFiles(chunk)["scripts/lib/common.sh"] != 2 {
+				t.Errorf("Expected the source line to relate to both chunks of the library, got %v", relatedFiles(chunk))
+			}
+		case slices.Contains(chunk.Symbols, "deploy"):
+			if relatedFiles(chunk)["scripts/lib/common.sh"] != 2 {
+				t.Errorf("Expected deploy to relate to setup_env and the exported variables, got %v", relatedFiles(chunk))
+			}
+		}
+	}
+}
+
+// TestShellChunkerSourcePaths tests that sourced paths are only resolved
+// relative to the script's own directory
+func TestShellChunkerSourcePaths(t *testing.T) {
+	script := `#!/bin/bash
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+CONFIG_DIR="$(dirname "$CONFIG_FILE")"
+
+source "$SCRIPT_DIR/lib/common.sh"
+. "${BASH_SOURCE%/*}/lib/log.sh"
+. "$(dirname "$0")/lib/retry.sh"
+. "$HOME/.bashrc"
+. "$CONFIG_DIR/settings.sh"
+source "${XDG_CONFIG_HOME}/app/env.sh"
+source ./local.sh
+`
+
+	chunks, err := chunker.NewShellChunker().Chunk("scripts/deploy.sh", []byte(script), model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 1000})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	if len(chunks) == 0 {
+		t.Fatal("Expected chunks, got none")
+	}
+
+	expected := []string{"scripts/lib/common.sh", "scripts/lib/log.sh", "scripts/lib/retry.sh", "scripts/local.sh"}
+	if !reflect.DeepEqual(chunks[0].Imports, expected) {
+		t.Errorf("Expected imports %v, got %v", expected, chunks[0].Imports)
+	}
+}
+
 // TestDockerfileChunker tests the Dockerfile chunker
 func TestDockerfileChunker(t *testing.T) {
 	chunkerImpl := chunker.NewDockerfileChunker()
@@ -344,3 +551,2455 @@ CMD ["echo", "Hello from Docker!"]
 		}
 	}
 }
+
+// TestNotebookChunker tests the Jupyter notebook chunker
+func TestNotebookChunker(t *testing.T) {
+	chunkerImpl := chunker.NewNotebookChunker()
+	symbolTable := model.NewSymbolTable()
+
+	// Notebook with a markdown cell, two small code cells and an image output
+	content := []byte(`{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# Analysis\n", "Load the data."]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [{"output_type": "display_data", "data": {"image/png": "iVBORw0KGgoAAAANSUhEUg"}}],
+   "source": ["import pandas as pd\n", "def load(path):\n", "    return pd.read_csv(path)"]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 2,
+   "metadata": {},
+   "outputs": [],
+   "source": "df = load('data.csv')"
+  }
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`)
+
+	chunks, err := chunkerImpl.Chunk("analysis.ipynb", content, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 5,
+		MaxChunkSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// The markdown cell stands alone and the two small code cells are merged
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Language != "markdown" {
+		t.Errorf("Expected markdown chunk, got %s", chunks[0].Language)
+	}
+	if chunks[1].Language != "python" {
+		t.Errorf("Expected python chunk, got %s", chunks[1].Language)
+	}
+
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.Content, "iVBORw0KGgo") {
+			t.Error("Cell outputs should be stripped from chunks")
+		}
+	}
+
+	wantSymbols := map[string]bool{"cell:1": false, "cell:2": false, "load": false}
+	for _, symbol := range chunks[1].Symbols {
+		if _, ok := wantSymbols[symbol]; ok {
+			wantSymbols[symbol] = true
+		}
+	}
+	for symbol, found := range wantSymbols {
+		if !found {
+			t.Errorf("Failed to extract %s symbol", symbol)
+		}
+	}
+
+	if chunks[1].StartLine != 8 || chunks[1].EndLine != 21 {
+		t.Errorf("Expected code chunk to span lines 8-21, got %d-%d", chunks[1].StartLine, chunks[1].EndLine)
+	}
+}
+
+// TestStarlarkChunker tests the Bazel/Starlark chunker
+func TestStarlarkChunker(t *testing.T) {
+	chunkerImpl := chunker.NewStarlarkChunker()
+	symbolTable := model.NewSymbolTable()
+
+	// BUILD file with a load, two targets and a dependency between them
+	content := []byte(`load("//tools:defs.bzl", "my_macro")
+
+package(default_visibility = ["//visibility:public"])
+
+# The core library
+go_library(
+    name = "core",
+    srcs = ["core.go"],
+    deps = [
+        ":util",
+        "//third_party/log",
+        "@com_github_pkg_errors//:errors",
+    ],
+)
+
+my_macro(
+    name = "util",
+    srcs = glob(["util/*.go"]),
+)
+`)
+
+	rootDir := "/repo"
+	chunks, err := chunkerImpl.Chunk("/repo/services/api/BUILD", content, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 5,
+		MaxChunkSize: 50,
+		RootDir:      rootDir,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// One chunk for the load/package preamble and one per target
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+
+	core := chunks[1]
+	if len(core.Symbols) != 1 || core.Symbols[0] != "//services/api:core" {
+		t.Errorf("Expected //services/api:core symbol, got %v", core.Symbols)
+	}
+	if core.StartLine != 5 || !strings.HasPrefix(core.Content, "# The core library") {
+		t.Errorf("Expected target chunk to start with its comment at line 5, got line %d", core.StartLine)
+	}
+	if len(core.Imports) != 1 || core.Imports[0] != "//tools:defs.bzl" {
+		t.Errorf("Expected load() to be recorded as an import, got %v", core.Imports)
+	}
+
+	for _, dep := range []string{"//services/api:util", "//third_party/log:log", "@com_github_pkg_errors//:errors"} {
+		refs := symbolTable.References[dep]
+		if len(refs) != 1 || refs[0].ChunkID != core.ID {
+			t.Errorf("Expected dependency %s to be referenced by the core target", dep)
+		}
+	}
+
+	if len(symbolTable.Definitions["//services/api:util"]) != 1 {
+		t.Error("Failed to define the //services/api:util target")
+	}
+	if len(symbolTable.References["my_macro"]) != 1 {
+		t.Error("Expected the macro invocation to be recorded as a reference")
+	}
+}
+
+// TestStarlarkPackageRoot tests that workspace markers are only looked for
+// up to the chunking root
+func TestStarlarkPackageRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	rootDir := filepath.Join(tmpDir, "tree")
+	if err := os.MkdirAll(filepath.Join(rootDir, "nested", "pkg"), 0o755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	for _, path := range []string{filepath.Join(tmpDir, "WORKSPACE"), filepath.Join(rootDir, "nested", "MODULE.bazel")} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		path     string
+		rootDir  string
+		expected string
+	}{
+		// The WORKSPACE above the root isn't used
+		{filepath.Join(rootDir, "lib", "BUILD"), rootDir, "//lib:lib"},
+		{filepath.Join(rootDir, "nested", "pkg", "BUILD"), rootDir, "//pkg:lib"},
+		// Without a root, only the file's directory is looked at
+		{filepath.Join(rootDir, "lib", "BUILD"), "", "//:lib"},
+	}
+	for _, test := range tests {
+		chunks, err := chunker.NewStarlarkChunker().Chunk(test.path, []byte("go_library(name = \"lib\")\n"), model.NewSymbolTable(), chunker.ChunkingOptions{
+			MaxChunkSize: 50,
+			RootDir:      test.rootDir,
+		})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		if len(chunks) != 1 || !reflect.DeepEqual(chunks[0].Symbols, []string{test.expected}) {
+			t.Errorf("Expected %s to define %s, got %v", test.path, test.expected, chunks)
+		}
+	}
+}
+
+// TestCMakeChunker tests the CMake chunker
+func TestCMakeChunker(t *testing.T) {
+	chunkerImpl := chunker.NewCMakeChunker()
+	symbolTable := model.NewSymbolTable()
+
+	// CMakeLists.txt with a function and two targets
+	content := []byte(`cmake_minimum_required(VERSION 3.16)
+project(demo LANGUAGES CXX)
+
+# Helper that adds warnings
+function(add_warnings target)
+  target_compile_options(${target} PRIVATE -Wall)
+endfunction()
+
+add_library(core src/core.cpp)
+target_include_directories(core PUBLIC include)
+
+add_executable(app main.cpp)
+target_link_libraries(app PRIVATE core Threads::Threads ${EXTRA_LIBS})
+target_compile_definitions(core PRIVATE "CORE_BUILD=1")
+`)
+
+	chunks, err := chunkerImpl.Chunk("CMakeLists.txt", content, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 5,
+		MaxChunkSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	chunksBySymbol := make(map[string]model.Chunk)
+	for _, chunk := range chunks {
+		for _, symbol := range chunk.Symbols {
+			chunksBySymbol[symbol] = chunk
+		}
+	}
+
+	function, ok := chunksBySymbol["add_warnings"]
+	if !ok {
+		t.Fatal("Failed to extract add_warnings function symbol")
+	}
+	if function.StartLine != 4 || function.EndLine != 7 {
+		t.Errorf("Expected function chunk to span lines 4-7, got %d-%d", function.StartLine, function.EndLine)
+	}
+
+	// The target chunk collects its target_* calls, even those further down the file
+	core, ok := chunksBySymbol["core"]
+	if !ok {
+		t.Fatal("Failed to extract core target symbol")
+	}
+	if !strings.Contains(core.Content, "target_include_directories") || !strings.Contains(core.Content, "CORE_BUILD") {
+		t.Errorf("Expected core target chunk to include its target_* calls, got:\n%s", core.Content)
+	}
+
+	app, ok := chunksBySymbol["app"]
+	if !ok {
+		t.Fatal("Failed to extract app target symbol")
+	}
+	for _, dep := range []string{"core", "Threads::Threads"} {
+		refs := symbolTable.References[dep]
+		if len(refs) != 1 || refs[0].ChunkID != app.ID {
+			t.Errorf("Expected %s to be referenced by the app target", dep)
+		}
+	}
+	if _, ok := symbolTable.References["${EXTRA_LIBS}"]; ok {
+		t.Error("Variables should not be recorded as target references")
+	}
+}
+
+// TestCMakeChunkerTrailingComments tests that a comment ending a command's
+// line isn't taken as the leading comment of the next command
+func TestCMakeChunkerTrailingComments(t *testing.T) {
+	content := []byte(`add_library(core src/core.cpp) # the core library
+add_executable(app main.cpp)
+set(A 1) set(B 2)
+`)
+
+	chunks, err := chunker.NewCMakeChunker().Chunk("CMakeLists.txt", content, model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	expected := [][2]int{{1, 1}, {2, 2}, {3, 3}}
+	var got [][2]int
+	for _, chunk := range chunks {
+		got = append(got, [2]int{chunk.StartLine, chunk.EndLine})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected chunks at lines %v, got %v", expected, got)
+	}
+}
+
+// TestXMLChunker tests the XML chunker on build descriptors
+func TestXMLChunker(t *testing.T) {
+	symbolTable := model.NewSymbolTable()
+
+	// Maven pom.xml with two dependencies, one with an exclusion
+	pom := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <groupId>com.example</groupId>
+  <artifactId>demo</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.9</version>
+      <exclusions>
+        <exclusion>
+          <groupId>org.foo</groupId>
+          <artifactId>bar</artifactId>
+        </exclusion>
+      </exclusions>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+    </dependency>
+  </dependencies>
+</project>
+`)
+
+	chunks, err := chunker.NewXMLChunker(nil).Chunk("pom.xml", pom, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 5,
+		MaxChunkSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	wantImports := map[string]string{
+		"/project/dependencies/dependency[1]": "org.slf4j:slf4j-api:2.0.9",
+		"/project/dependencies/dependency[2]": "junit:junit",
+	}
+	for _, chunk := range chunks {
+		if len(chunk.Symbols) != 1 {
+			continue
+		}
+		if want, ok := wantImports[chunk.Symbols[0]]; ok {
+			if len(chunk.Imports) != 1 || chunk.Imports[0] != want {
+				t.Errorf("Expected %s imports to be [%s], got %v", chunk.Symbols[0], want, chunk.Imports)
+			}
+			delete(wantImports, chunk.Symbols[0])
+		}
+	}
+	for symbol := range wantImports {
+		t.Errorf("Failed to extract %s chunk", symbol)
+	}
+
+	// .csproj split on a configured element path
+	csproj := []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.3" />
+    <ProjectReference Include="..\Core\Core.csproj" />
+  </ItemGroup>
+</Project>
+`)
+
+	chunks, err = chunker.NewXMLChunker([]string{"ItemGroup"}).Chunk("App.csproj", csproj, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 5,
+		MaxChunkSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// The project header, the item group and the closing tag of the project
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	itemGroup := chunks[1]
+	if len(itemGroup.Symbols) != 1 || itemGroup.Symbols[0] != "/Project/ItemGroup[1]" {
+		t.Errorf("Expected /Project/ItemGroup[1] symbol, got %v", itemGroup.Symbols)
+	}
+	if strings.Join(itemGroup.Imports, ",") != "Newtonsoft.Json@13.0.3,../Core/Core.csproj" {
+		t.Errorf("Unexpected ItemGroup imports %v", itemGroup.Imports)
+	}
+}
+
+// TestConfigChunker tests the section-aware config chunker
+func TestConfigChunker(t *testing.T) {
+	chunkerImpl := chunker.NewConfigChunker()
+	symbolTable := model.NewSymbolTable()
+
+	// TOML file with an inline table and a multi-line string that looks like a header
+	content := []byte(`title = "demo"
+
+[server]
+host = "0.0.0.0"
+tls = { cert = "server.pem",
+        key = "server.key" }
+
+# Products sold
+[[products]]
+name = "Hammer"
+description = """
+[not a header]
+"""
+
+[[products]]
+name = "Nail"
+`)
+
+	chunks, err := chunkerImpl.Chunk("config.toml", content, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 3,
+		MaxChunkSize: 6,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	var symbols []string
+	for _, chunk := range chunks {
+		if chunk.Language != "toml" {
+			t.Errorf("Expected toml language, got %s", chunk.Language)
+		}
+		symbols = append(symbols, strings.Join(chunk.Symbols, "+"))
+
+		// No chunk may end inside the inline table or the multi-line string
+		if strings.Count(chunk.Content, "{") != strings.Count(chunk.Content, "}") ||
+			strings.Count(chunk.Content, `"""`)%2 != 0 {
+			t.Errorf("Chunk at lines %d-%d splits a value:\n%s", chunk.StartLine, chunk.EndLine, chunk.Content)
+		}
+	}
+
+	// The preamble and [server] would overflow together, the oversized first
+	// product is split between entries and the second product stands alone
+	want := "|server|products[1]|products[1]|products[2]"
+	if got := strings.Join(symbols, "|"); got != want {
+		t.Errorf("Expected section symbols %s, got %s", want, got)
+	}
+
+	if !strings.HasPrefix(chunks[2].Content, "# Products sold") {
+		t.Error("Expected the comment above a header to belong to its section")
+	}
+}
+
+// TestProseChunker tests the prose chunker on reStructuredText and plain text
+func TestProseChunker(t *testing.T) {
+	chunkerImpl := chunker.NewProseChunker()
+	symbolTable := model.NewSymbolTable()
+
+	rst := []byte(`=====
+Guide
+=====
+
+Welcome to the guide.
+
+Install
+=======
+
+Run the installer.
+
+From source
+-----------
+
+Clone the repository and build it.
+
+Usage
+=====
+
+Run the tool.
+`)
+
+	chunks, err := chunkerImpl.Chunk("guide.rst", rst, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize:   5,
+		MaxChunkSize:   50,
+		MaxChunkTokens: 100,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	var breadcrumbs []string
+	for _, chunk := range chunks {
+		breadcrumbs = append(breadcrumbs, strings.Join(chunk.Symbols, ""))
+	}
+	want := "Guide|Guide > Install|Guide > Install > From source|Guide > Usage"
+	if got := strings.Join(breadcrumbs, "|"); got != want {
+		t.Errorf("Expected breadcrumbs %s, got %s", want, got)
+	}
+	if chunks[2].StartLine != 12 || chunks[2].EndLine != 15 {
+		t.Errorf("Expected From source chunk to span lines 12-15, got %d-%d", chunks[2].StartLine, chunks[2].EndLine)
+	}
+
+	// A plain text paragraph over budget is split between sentences
+	sentence := "This sentence is about forty characters. "
+	text := []byte(strings.Repeat(sentence, 10) + "\n\nA short closing paragraph.\n")
+
+	chunks, err = chunkerImpl.Chunk("notes.txt", text, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize:   5,
+		MaxChunkSize:   50,
+		MaxChunkTokens: 30,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	if len(chunks) < 4 {
+		t.Fatalf("Expected the long paragraph to be split, got %d chunks", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if chunk.TokenCount > 30 {
+			t.Errorf("Chunk exceeds the token budget with %d tokens", chunk.TokenCount)
+		}
+		if chunk.Language != "text" {
+			t.Errorf("Expected text language, got %s", chunk.Language)
+		}
+		if !strings.HasSuffix(chunk.Content, ".") {
+			t.Errorf("Expected chunk to end at a sentence boundary: %q", chunk.Content)
+		}
+	}
+
+	// Text without spaces is cut between runes
+	cjk := strings.Repeat("日本語の文章", 20)
+	chunks, err = chunkerImpl.Chunk("notes.txt", []byte(cjk+"\n"), symbolTable, chunker.ChunkingOptions{
+		MinChunkSize:   5,
+		MaxChunkSize:   50,
+		MaxChunkTokens: 10,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	if len(chunks) < 2 {
+		t.Fatalf("Expected the text without spaces to be split, got %d chunks", len(chunks))
+	}
+	var joined strings.Builder
+	for _, chunk := range chunks {
+		if !utf8.ValidString(chunk.Content) {
+			t.Errorf("Expected chunk to be valid UTF-8: %q", chunk.Content)
+		}
+		joined.WriteString(chunk.Content)
+	}
+	if joined.String() != cjk {
+		t.Errorf("Expected the chunks to make up the text, got %q", joined.String())
+	}
+}
+
+func TestTemplateChunker(t *testing.T) {
+	chunkerImpl := chunker.NewTemplateChunker()
+	symbolTable := model.NewSymbolTable()
+
+	// A minimal Helm chart with a helper, a manifest and values
+	chartDir, err := os.MkdirTemp("", "chunker-helm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(chartDir)
+
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: web\n",
+		"values.yaml": `image:
+  repository: nginx
+  tag: ""
+
+containers:
+- name: app
+
+service:
+  port: 80
+`,
+		"templates/_helpers.tpl": `{{/*
+Expand the name of the chart.
+*/}}
+{{- define "web.name" -}}
+{{- .Chart.Name | trunc 63 }}
+{{- end }}
+
+{{- define "web.fullname" -}}
+{{- if .Values.fullnameOverride }}{{ .Values.fullnameOverride }}{{ else }}{{ include "web.name" . }}{{ end }}
+{{- end }}
+`,
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "web.fullname" . }}
+spec:
+  image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+  port: {{ index .Values "service" "port" }}
+---
+apiVersion: v1
+kind: Service
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(chartDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := chunker.ChunkingOptions{RootDir: chartDir, MinChunkSize: 1, MaxChunkSize: 50}
+	chunk := func(name string) []model.Chunk {
+		path := filepath.Join(chartDir, name)
+		content, _ := os.ReadFile(path)
+		chunks, err := chunkerImpl.Chunk(path, content, symbolTable, options)
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		return chunks
+	}
+
+	// One chunk per define, each with the comment above it
+	helpers := chunk("templates/_helpers.tpl")
+	if len(helpers) != 2 || helpers[0].Symbols[0] != "web.name" || helpers[1].Symbols[0] != "web.fullname" {
+		t.Fatalf("Expected web.name and web.fullname define chunks, got %d chunks", len(helpers))
+	}
+	if !strings.HasPrefix(helpers[0].Content, "{{/*") {
+		t.Error("Expected the comment above a define to belong to its chunk")
+	}
+
+	// One chunk per YAML document
+	manifests := chunk("templates/deployment.yaml")
+	if len(manifests) != 2 || manifests[1].StartLine != 8 {
+		t.Fatalf("Expected a chunk per YAML document, got %d chunks", len(manifests))
+	}
+	if manifests[0].Framework != "helm" || manifests[0].Language != "yaml" {
+		t.Errorf("Expected a helm yaml chunk, got %s/%s", manifests[0].Framework, manifests[0].Language)
+	}
+
+	values := chunk("values.yaml")
+	for _, symbol := range []string{"web:.Values.image", "web:.Values.image.tag", "web:.Values.service.port"} {
+		if len(symbolTable.Definitions[symbol]) != 1 {
+			t.Errorf("Expected values key %s to be defined", symbol)
+		}
+	}
+	if len(symbolTable.Definitions["web:.Values.containers.name"]) != 0 {
+		t.Error("Keys inside lists can't be addressed through .Values")
+	}
+
+	// Template calls and values usages link across files
+	refs := map[string]string{
+		"web.name":                     helpers[1].ID,
+		"web.fullname":                 manifests[0].ID,
+		"web:.Values.image.repository": manifests[0].ID,
+		"web:.Values.service.port":     manifests[0].ID,
+	}
+	for symbol, chunkID := range refs {
+		if found := symbolTable.References[symbol]; len(found) != 1 || found[0].ChunkID != chunkID {
+			t.Errorf("Expected %s to be referenced once by its using chunk, got %v", symbol, found)
+		}
+	}
+
+	for _, c := range append(helpers, append(manifests, values...)...) {
+		symbolTable.AddChunk(c)
+	}
+	related := symbolTable.FindRelatedChunks(manifests[0])
+	found := false
+	for _, id := range related {
+		if id == values[0].ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the deployment to be related to the image values chunk")
+	}
+}
+
+func TestComposeChunker(t *testing.T) {
+	chunkerImpl := chunker.NewComposeChunker()
+	symbolTable := model.NewSymbolTable()
+
+	content := []byte(`name: shop
+
+services:
+  # The API server
+  api:
+    build:
+      context: ./api
+      dockerfile: Dockerfile.prod
+    env_file: .env
+    depends_on: [db]
+    volumes:
+      - data:/var/lib/data
+      - ./src:/src
+
+  db:
+    image: postgres
+
+volumes:
+  data: {}
+`)
+
+	chunks, err := chunkerImpl.Chunk("/repo/docker-compose.yml", content, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 1,
+		MaxChunkSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// The name preamble, two services and one volume
+	var symbols []string
+	for _, chunk := range chunks {
+		symbols = append(symbols, strings.Join(chunk.Symbols, "+"))
+	}
+	want := "|shop:services.api|shop:services.db|shop:volumes.data"
+	if got := strings.Join(symbols, "|"); got != want {
+		t.Fatalf("Expected entry symbols %s, got %s", want, got)
+	}
+
+	api := chunks[1]
+	if !strings.HasPrefix(api.Content, "services:\n  # The API server") {
+		t.Error("Expected the first service to own the section header and its comment")
+	}
+	for _, symbol := range []string{"shop:services.db", "shop:volumes.data"} {
+		if refs := symbolTable.References[symbol]; len(refs) != 1 || refs[0].ChunkID != api.ID {
+			t.Errorf("Expected %s to be referenced by the api service", symbol)
+		}
+	}
+	if len(symbolTable.References["shop:volumes../src"]) != 0 {
+		t.Error("Bind mounts aren't named volumes")
+	}
+
+	// The service relates to the Dockerfile it builds and its env file
+	dockerfile, err := chunker.NewDockerfileChunker().Chunk("/repo/api/Dockerfile.prod", []byte("FROM golang\n"), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	for _, chunk := range append(chunks, dockerfile...) {
+		symbolTable.AddChunk(chunk)
+	}
+	if len(symbolTable.FileReferences["/repo/.env"]) != 1 {
+		t.Error("Expected the env file to be referenced")
+	}
+
+	related := symbolTable.FindRelatedChunks(dockerfile[0])
+	if len(related) == 0 || related[0] != api.ID {
+		t.Errorf("Expected the Dockerfile to be related to the api service, got %v", related)
+	}
+
+	// An oversized service is split between its keys, and each part records what it uses
+	large := []byte(`services:
+  web:
+    image: nginx
+    # Ports the proxy listens on
+    ports:
+      - "80:80"
+      - "443:443"
+    environment:
+      - MODE=proxy
+      - LEVEL=info
+    depends_on:
+      - api
+`)
+	chunks, err = chunkerImpl.Chunk("/repo/proxy/docker-compose.yml", large, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 1,
+		MaxChunkSize: 5,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	var ranges []string
+	for _, chunk := range chunks {
+		if len(chunk.Symbols) != 1 || chunk.Symbols[0] != "proxy:services.web" {
+			t.Errorf("Expected every part to be the web service, got %v", chunk.Symbols)
+		}
+		ranges = append(ranges, fmt.Sprintf("%d-%d", chunk.StartLine, chunk.EndLine))
+	}
+	if got := strings.Join(ranges, ","); got != "1-3,4-7,8-12" {
+		t.Errorf("Expected the web service to be split at its keys, got %s", got)
+	}
+	if refs := symbolTable.References["proxy:services.api"]; len(refs) != 1 || refs[0].ChunkID != chunks[len(chunks)-1].ID {
+		t.Error("Expected the depends_on reference to come from the part holding it")
+	}
+}
+
+func TestGoChunkerTypeCheck(t *testing.T) {
+	// Two packages that both define New
+	tmpDir, err := os.MkdirTemp("", "chunker-types")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+import "strings"
+
+type Conn struct {
+	name string
+}
+
+func New() *Conn {
+	return &Conn{}
+}
+`,
+		"a/use.go": `package a
+
+func Use() string {
+	conn := New()
+	return strings.ToUpper(conn.name)
+}
+`,
+		"b/b.go": `package b
+
+func New() int {
+	return 0
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	chunkerImpl := chunker.NewGoChunker()
+	chunkerImpl.TypeCheck = true
+	symbolTable := model.NewSymbolTable()
+
+	var useChunk model.Chunk
+	for _, name := range []string{"a/a.go", "b/b.go", "a/use.go"} {
+		path := filepath.Join(tmpDir, name)
+		content, _ := os.ReadFile(path)
+		chunks, err := chunkerImpl.Chunk(path, content, symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+			if len(chunk.Symbols) == 1 && chunk.Symbols[0] == "Use" {
+				useChunk = chunk
+			}
+		}
+	}
+
+	// Use refers to a.New and, through the field, to Conn. The stdlib call isn't recorded
+	var refs []model.SymbolReference
+	for _, ref := range symbolTable.References["New"] {
+		if ref.Kind == "" {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) != 1 || refs[0].DefinitionFile != filepath.Join(tmpDir, "a/a.go") || refs[0].FilePath != useChunk.FilePath {
+		t.Fatalf("Expected one reference to New resolved to a/a.go, got %+v", refs)
+	}
+	fieldRef := false
+	for _, ref := range symbolTable.References["Conn"] {
+		fieldRef = fieldRef || ref.ChunkID == useChunk.ID
+	}
+	if !fieldRef {
+		t.Error("Expected the field access in Use to reference Conn")
+	}
+	if len(symbolTable.References["ToUpper"]) != 0 {
+		t.Error("Expected standard library references to be skipped")
+	}
+
+	// Only the New that Use calls is related
+	bNew := symbolTable.Definitions["New"]
+	for _, id := range symbolTable.FindRelatedChunks(useChunk) {
+		for _, def := range bNew {
+			if id == def.ChunkID && strings.HasSuffix(def.FilePath, "b.go") {
+				t.Error("Expected b.New not to be related to a.Use")
+			}
+		}
+	}
+}
+
+func TestGoChunkerSplitsLongFunctions(t *testing.T) {
+	chunkerImpl := chunker.NewGoChunker()
+	symbolTable := model.NewSymbolTable()
+
+	content := []byte(`package big
+
+func Handle(kind string, n int) int {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += i
+	}
+
+	switch kind {
+	case "a":
+		total++
+		total++
+	case "b":
+		total--
+		total--
+	}
+	return total
+}
+`)
+
+	chunks, err := chunkerImpl.Chunk("big.go", content, symbolTable, chunker.ChunkingOptions{
+		MinChunkSize: 1,
+		MaxChunkSize: 7,
+	})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	var parts []model.Chunk
+	for _, chunk := range chunks {
+		if strings.HasPrefix(chunk.Symbols[0], "Handle") {
+			parts = append(parts, chunk)
+		}
+	}
+	if len(parts) < 3 {
+		t.Fatalf("Expected the function to be split into at least 3 parts, got %d", len(parts))
+	}
+
+	if parts[0].Symbols[0] != "Handle" || parts[0].ParentID != "" {
+		t.Errorf("Expected the first part to keep the function symbol, got %v", parts[0].Symbols)
+	}
+	for i, part := range parts {
+		if strings.Count(part.Content, "\n")+1 > 7 {
+			t.Errorf("Part %d exceeds the size budget:\n%s", i+1, part.Content)
+		}
+		if !strings.HasPrefix(part.Content, "func Handle(kind string, n int) int {") {
+			t.Errorf("Expected part %d to start with the signature", i+1)
+		}
+		if i == 0 {
+			continue
+		}
+		if part.Symbols[0] != fmt.Sprintf("Handle#part%d", i+1) || part.ParentID != parts[0].ID {
+			t.Errorf("Expected part %d to be Handle#part%d linked to the first part, got %v", i+1, i+1, part.Symbols)
+		}
+	}
+
+	// A case clause split from its switch keeps the switch as context
+	for _, part := range parts {
+		if strings.Contains(part.Content, `case "b":`) && !strings.Contains(part.Content, "switch kind {") {
+			t.Errorf("Expected the switch statement in the header of:\n%s", part.Content)
+		}
+	}
+}
+
+func TestGoChunkerDocComments(t *testing.T) {
+	chunkerImpl := chunker.NewGoChunker()
+	symbolTable := model.NewSymbolTable()
+
+	content := []byte(`//go:build linux && amd64
+
+package doc
+
+//go:generate stringer -type=Kind
+
+// Kind is a kind
+type Kind int
+
+// Hello says hello.
+//
+//go:noinline
+func Hello() {
+} //nolint:unused
+
+var x = 1
+`)
+
+	chunks, err := chunkerImpl.Chunk("doc.go", content, symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	bySymbol := make(map[string]model.Chunk)
+	for _, chunk := range chunks {
+		if chunk.Kind == "package" {
+			continue
+		}
+		bySymbol[chunk.Symbols[0]] = chunk
+		if chunk.Metadata["build"] != "linux && amd64" {
+			t.Errorf("Expected the build constraint on the %s chunk, got %v", chunk.Symbols[0], chunk.Metadata)
+		}
+	}
+
+	if kind := bySymbol["Kind"]; kind.StartLine != 5 || !strings.Contains(kind.Content, "// Kind is a kind") {
+		t.Errorf("Expected the Kind chunk to start with its go:generate directive and doc comment, got line %d", kind.StartLine)
+	}
+
+	hello := bySymbol["Hello"]
+	if !strings.HasPrefix(hello.Content, "// Hello says hello.") || !strings.Contains(hello.Content, "//go:noinline") {
+		t.Errorf("Expected the Hello chunk to include its doc comment and directive:\n%s", hello.Content)
+	}
+	if !strings.HasSuffix(hello.Content, "} //nolint:unused") {
+		t.Errorf("Expected the trailing nolint marker in the Hello chunk:\n%s", hello.Content)
+	}
+	if x := bySymbol["x"]; x.StartLine != 16 {
+		t.Errorf("Expected the trailing marker of Hello not to be attached to x, got line %d", x.StartLine)
+	}
+}
+
+func TestGoChunkerLinksTests(t *testing.T) {
+	chunkerImpl := chunker.NewGoChunker()
+	symbolTable := model.NewSymbolTable()
+	options := chunker.ChunkingOptions{MaxChunkSize: 50}
+
+	source := []byte(`package calc
+
+func Add(a, b int) int { return a + b }
+
+func parseExpr(s string) int { return len(s) }
+
+func normalize(s string) string { return s }
+
+type Stack struct{}
+
+func (s *Stack) Push(v int) {}
+`)
+
+	tests := []byte(`package calc
+
+import "testing"
+
+var parseExprTests = []struct {
+	in   string
+	want int
+}{
+	{"1", 1},
+	{"22", 2},
+}
+
+func TestAdd(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   func(string) string
+	}{
+		{"identity", normalize},
+		{"again", normalize},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if Add(1, 2) != 3 {
+				t.Fatal(tc.fn("x"))
+			}
+		})
+	}
+}
+
+func TestParseExpr(t *testing.T) {}
+
+func BenchmarkAdd(b *testing.B) {}
+
+func ExampleStack_Push() {}
+
+func FuzzAdd(f *testing.F) {}
+
+func Testify() {}
+`)
+
+	sourceChunks, err := chunkerImpl.Chunk("calc/calc.go", source, symbolTable, options)
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	testChunks, err := chunkerImpl.Chunk("calc/calc_test.go", tests, symbolTable, options)
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	for _, chunk := range append(sourceChunks, testChunks...) {
+		symbolTable.AddChunk(chunk)
+	}
+
+	bySymbol := make(map[string]model.Chunk)
+	for _, chunk := range append(sourceChunks, testChunks...) {
+		if len(chunk.Symbols) > 0 {
+			bySymbol[chunk.FilePath+":"+chunk.Symbols[0]] = chunk
+		}
+	}
+
+	expectedKinds := map[string]string{
+		"TestAdd":           "test",
+		"TestParseExpr":     "test",
+		"BenchmarkAdd":      "benchmark",
+		"ExampleStack_Push": "example",
+		"FuzzAdd":           "fuzz",
+		"Testify":           "",
+		"parseExprTests":    "test_table",
+	}
+	for symbol, kind := range expectedKinds {
+		if got := bySymbol["calc/calc_test.go:"+symbol].Kind; got != kind {
+			t.Errorf("Expected %s to have kind %q, got %q", symbol, kind, got)
+		}
+	}
+
+	if cases := bySymbol["calc/calc_test.go:TestAdd"].Metadata["test_cases"]; cases != "2" {
+		t.Errorf("Expected TestAdd to have 2 test cases, got %q", cases)
+	}
+
+	related := func(symbol string) map[string]bool {
+		ids := make(map[string]bool)
+		for _, id := range symbolTable.FindRelatedChunks(bySymbol[symbol]) {
+			ids[id] = true
+		}
+		return ids
+	}
+
+	addRelated := related("calc/calc.go:Add")
+	for _, test := range []string{"TestAdd", "BenchmarkAdd", "FuzzAdd"} {
+		if !addRelated[bySymbol["calc/calc_test.go:"+test].ID] {
+			t.Errorf("Expected %s to be related to Add", test)
+		}
+	}
+
+	parseRelated := related("calc/calc.go:parseExpr")
+	for _, test := range []string{"TestParseExpr", "parseExprTests"} {
+		if !parseRelated[bySymbol["calc/calc_test.go:"+test].ID] {
+			t.Errorf("Expected %s to be related to parseExpr", test)
+		}
+	}
+
+	if !related("calc/calc.go:normalize")[bySymbol["calc/calc_test.go:TestAdd"].ID] {
+		t.Error("Expected the table of TestAdd to relate it to normalize")
+	}
+	if !related("calc/calc.go:Stack.Push")[bySymbol["calc/calc_test.go:ExampleStack_Push"].ID] {
+		t.Error("Expected ExampleStack_Push to be related to Stack.Push")
+	}
+}
+
+func TestGoChunkerInterfaceRelations(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "chunker-interfaces")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filePath := filepath.Join(tmpDir, "shapes", "shapes.go")
+	content := []byte(`package shapes
+
+type Shape interface {
+	Namer
+	Area() float64
+}
+
+type Namer interface {
+	Name() string
+}
+
+type base struct{}
+
+func (base) Name() string { return "" }
+
+type Square struct {
+	base
+	side float64
+}
+
+func (s Square) Area() float64 { return s.side * s.side }
+
+type Label struct{}
+
+func (l *Label) Name() string { return "label" }
+
+type Number interface {
+	~int | ~float64
+}
+
+func Sum[T Number](xs []T) T {
+	var total T
+	return total
+}
+`)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for _, typeCheck := range []bool{false, true} {
+		chunkerImpl := chunker.NewGoChunker()
+		chunkerImpl.TypeCheck = typeCheck
+		symbolTable := model.NewSymbolTable()
+
+		chunks, err := chunkerImpl.Chunk(filePath, content, symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+
+		bySymbol := make(map[string]model.Chunk)
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+			if len(chunk.Symbols) > 0 {
+				bySymbol[chunk.Symbols[0]] = chunk
+			}
+		}
+
+		// Implementers, whether found by method sets or by type checking
+		implementers := func(iface string) map[string]bool {
+			result := make(map[string]bool)
+			for _, id := range symbolTable.Implementations(bySymbol[iface].ID) {
+				result[symbolTable.Chunks[id].Symbols[0]] = true
+			}
+			for _, ref := range symbolTable.References[iface] {
+				if ref.Kind == "implements" {
+					result[symbolTable.Chunks[ref.ChunkID].Symbols[0]] = true
+				}
+			}
+			return result
+		}
+
+		shape := implementers("Shape")
+		if len(shape) != 1 || !shape["Square"] {
+			t.Errorf("TypeCheck=%v: expected only Square to implement Shape, got %v", typeCheck, shape)
+		}
+		namer := implementers("Namer")
+		for _, typ := range []string{"base", "Square", "Label"} {
+			if !namer[typ] {
+				t.Errorf("TypeCheck=%v: expected %s to implement Namer, got %v", typeCheck, typ, namer)
+			}
+		}
+
+		hasRelation := func(from string, to string, kind string) bool {
+			for _, ref := range symbolTable.References[to] {
+				if ref.ChunkID == bySymbol[from].ID && ref.Kind == kind {
+					return true
+				}
+			}
+			return false
+		}
+		if !hasRelation("Square", "base", "embeds") || !hasRelation("Shape", "Namer", "embeds") {
+			t.Errorf("TypeCheck=%v: expected embedding relations from Square and Shape", typeCheck)
+		}
+		if !hasRelation("Sum", "Number", "constraint") {
+			t.Errorf("TypeCheck=%v: expected Sum to be constrained by Number", typeCheck)
+		}
+	}
+}
+
+func TestGoChunkerGroupedTypeImplementations(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "chunker-grouped-types")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filePath := filepath.Join(tmpDir, "files", "files.go")
+	content := []byte(`package files
+
+type ReadCloser interface {
+	Read() string
+	Close() error
+}
+
+type reader struct{}
+
+func (reader) Read() string { return "" }
+
+type closer struct{}
+
+func (closer) Close() error { return nil }
+
+type (
+	Source struct {
+		reader
+	}
+
+	Sink struct {
+		closer
+	}
+)
+
+type (
+	Namer interface {
+		Name() string
+	}
+
+	file struct{}
+)
+
+func (file) Name() string { return "file" }
+`)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for _, typeCheck := range []bool{false, true} {
+		chunkerImpl := chunker.NewGoChunker()
+		chunkerImpl.TypeCheck = typeCheck
+		symbolTable := model.NewSymbolTable()
+
+		chunks, err := chunkerImpl.Chunk(filePath, content, symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+
+		bySymbol := make(map[string]model.Chunk)
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+			if len(chunk.Symbols) > 0 {
+				bySymbol[chunk.Symbols[0]] = chunk
+			}
+		}
+
+		// Implementing chunks, whether found by method sets or by type checking
+		implementers := func(iface string) map[string]bool {
+			result := make(map[string]bool)
+			for _, id := range symbolTable.Implementations(bySymbol[iface].ID) {
+				result[symbolTable.Chunks[id].Symbols[0]] = true
+			}
+			for _, ref := range symbolTable.References[iface] {
+				if ref.Kind == "implements" {
+					result[symbolTable.Chunks[ref.ChunkID].Symbols[0]] = true
+				}
+			}
+			return result
+		}
+
+		// Source only reads and Sink only closes, whatever the other one in the group embeds
+		if readClosers := implementers("ReadCloser"); len(readClosers) != 0 {
+			t.Errorf("TypeCheck=%v: expected nothing to implement ReadCloser, got %v", typeCheck, readClosers)
+		}
+		if namers := implementers("Namer"); !namers["Namer"] {
+			t.Errorf("TypeCheck=%v: expected file to implement Namer in its own group, got %v", typeCheck, namers)
+		}
+	}
+}
+
+func TestGoChunkerResolvesModuleImports(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "chunker-modules")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"go.work":       "go 1.22\n\nuse (\n\t./app\n\t./lib\n)\n",
+		"app/go.mod":    "module example.com/app\n\nreplace example.com/legacy => ../legacy\n",
+		"lib/go.mod":    "module example.com/lib\n",
+		"legacy/go.mod": "module legacy\n",
+		"other/go.mod":  "module example.com/other\n",
+		"app/main.go": `package main
+
+import (
+	"example.com/legacy/util"
+	"example.com/lib/model"
+)
+
+func run() {
+	util.Do(model.Name)
+}
+`,
+		"lib/model/model.go":   "package model\n\nconst Name = \"lib\"\n",
+		"legacy/util/util.go":  "package util\n\nfunc Do(string) {}\n",
+		"other/model/model.go": "package model\n\nconst Other = \"other\"\n",
+		"other/util/util.go":   "package util\n\nfunc Do(string) {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	chunkerImpl := chunker.NewGoChunker()
+	symbolTable := model.NewSymbolTable()
+
+	bySymbol := make(map[string]model.Chunk)
+	for _, name := range []string{"lib/model/model.go", "legacy/util/util.go", "other/model/model.go", "other/util/util.go", "app/main.go"} {
+		path := filepath.Join(tmpDir, name)
+		chunks, err := chunkerImpl.Chunk(path, []byte(files[name]), symbolTable, chunker.ChunkingOptions{RootDir: tmpDir, MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+			if len(chunk.Symbols) > 0 {
+				bySymbol[name+":"+chunk.Symbols[0]] = chunk
+			}
+		}
+	}
+
+	expectedPackages := map[string]string{
+		"app/main.go:run":            "example.com/app",
+		"lib/model/model.go:Name":    "example.com/lib/model",
+		"legacy/util/util.go:Do":     "legacy/util",
+		"other/model/model.go:Other": "example.com/other/model",
+	}
+	for symbol, pkg := range expectedPackages {
+		if got := bySymbol[symbol].Metadata["package"]; got != pkg {
+			t.Errorf("Expected %s to be in package %q, got %q", symbol, pkg, got)
+		}
+	}
+
+	related := make(map[string]bool)
+	for _, id := range symbolTable.FindRelatedChunks(bySymbol["app/main.go:run"]) {
+		related[id] = true
+	}
+	if !related[bySymbol["lib/model/model.go:Name"].ID] {
+		t.Error("Expected run to be related to the workspace's lib/model package")
+	}
+	if !related[bySymbol["legacy/util/util.go:Do"].ID] {
+		t.Error("Expected run to be related to the replaced legacy/util package")
+	}
+	if related[bySymbol["other/model/model.go:Other"].ID] || related[bySymbol["other/util/util.go:Do"].ID] {
+		t.Error("Expected run not to be related to other packages with the same names")
+	}
+}
+
+func TestGoChunkerModuleRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// The go.mod and go.work above the chunking root belong to another tree
+	files := map[string]string{
+		"go.mod":           "module example.com/outer\n",
+		"go.work":          "go 1.22\n\nuse ./tree\n",
+		"tree/store/db.go": "// Package store keeps records.\npackage store\n\nfunc Open() {}\n",
+		"tree/cmd/main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	root := filepath.Join(tmpDir, "tree")
+	chunks, err := chunker.NewGoChunker().Chunk(filepath.Join(root, "store", "db.go"), []byte(files["tree/store/db.go"]), model.NewSymbolTable(), chunker.ChunkingOptions{RootDir: root, MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// Outside a module, the package is named by its directory relative to the root
+	summary := false
+	for _, chunk := range chunks {
+		if pkg := chunk.Metadata["package"]; pkg != "" {
+			t.Errorf("Expected %v not to be in a module, got package %q", chunk.Symbols, pkg)
+		}
+		if chunk.Kind == "package" {
+			summary = true
+			if !reflect.DeepEqual(chunk.Symbols, []string{"store"}) {
+				t.Errorf("Expected the package summary to be named store, got %v", chunk.Symbols)
+			}
+		}
+	}
+	if !summary {
+		t.Error("Expected a package summary chunk")
+	}
+}
+
+func TestGoChunkerSameNamedPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"go.work":          "go 1.22\n\nuse (\n\t./app\n\t./a\n\t./b\n)\n",
+		"app/go.mod":       "module example.com/app\n",
+		"a/go.mod":         "module example.com/a\n",
+		"b/go.mod":         "module example.com/b\n",
+		"a/model/model.go": "package model\n\ntype Chunk struct{ ID string }\n",
+		"b/model/model.go": "package model\n\ntype Chunk struct{ Name string }\n",
+		"app/main.go": `package main
+
+import "example.com/a/model"
+
+func run() model.Chunk {
+	return model.Chunk{}
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	chunkerImpl := chunker.NewGoChunker()
+	symbolTable := model.NewSymbolTable()
+
+	bySymbol := make(map[string]model.Chunk)
+	for _, name := range []string{"a/model/model.go", "b/model/model.go", "app/main.go"} {
+		path := filepath.Join(tmpDir, name)
+		chunks, err := chunkerImpl.Chunk(path, []byte(files[name]), symbolTable, chunker.ChunkingOptions{RootDir: tmpDir, MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+			if len(chunk.Symbols) > 0 && chunk.Kind != "package" {
+				bySymbol[name+":"+chunk.Symbols[0]] = chunk
+			}
+		}
+	}
+
+	// The chunk using a.Chunk is related from a's type, but not from b's
+	run := bySymbol["app/main.go:run"].ID
+	if !slices.Contains(symbolTable.FindRelatedChunks(bySymbol["a/model/model.go:Chunk"]), run) {
+		t.Error("Expected example.com/a/model.Chunk to be related to the function using it")
+	}
+	if slices.Contains(symbolTable.FindRelatedChunks(bySymbol["b/model/model.go:Chunk"]), run) {
+		t.Error("Expected example.com/b/model.Chunk not to be related to a function using example.com/a/model.Chunk")
+	}
+}
+
+func TestGoChunkerGeneratedCode(t *testing.T) {
+	content := []byte(`// Code generated by protoc-gen-go. DO NOT EDIT.
+
+package pb
+
+type Msg struct {
+	state int
+	Name  string ` + "`json:\"name\"`" + `
+}
+
+func (x *Msg) GetName() string {
+	return x.Name
+}
+
+func (x *Msg) reset() {}
+
+var file_msg_rawDesc = []byte{0x0a}
+`)
+
+	keep := chunker.NewGoChunker()
+	chunks, err := keep.Chunk("msg.pb.go", content, model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	if len(chunks) < 3 {
+		t.Fatalf("Expected generated code to be chunked normally by default, got %d chunks", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if chunk.Kind == "package" {
+			continue
+		}
+		if chunk.Metadata["generated"] != "true" {
+			t.Errorf("Expected the %v chunk to be marked as generated", chunk.Symbols)
+		}
+	}
+
+	skip := chunker.NewGoChunker()
+	skip.Generated = chunker.GeneratedSkip
+	chunks, err = skip.Chunk("msg.pb.go", content, model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Errorf("Expected generated code to be skipped, got %d chunks", len(chunks))
+	}
+
+	summarize := chunker.NewGoChunker()
+	summarize.Generated = chunker.GeneratedSummarize
+	symbolTable := model.NewSymbolTable()
+	chunks, err = summarize.Chunk("msg.pb.go", content, symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	// Besides the package's API summary
+	if len(chunks) != 2 || chunks[1].Kind != "package" {
+		t.Fatalf("Expected a single summary chunk, got %d chunks", len(chunks))
+	}
+
+	summary := chunks[0]
+	if summary.Kind != "summary" || summary.Metadata["generated"] != "true" {
+		t.Errorf("Expected a generated summary chunk, got kind %q and metadata %v", summary.Kind, summary.Metadata)
+	}
+	if !strings.Contains(summary.Content, "func (x *Msg) GetName() string\n") || !strings.Contains(summary.Content, "\tName string\n") {
+		t.Errorf("Expected exported signatures in the summary:\n%s", summary.Content)
+	}
+	for _, omitted := range []string{"return x.Name", "reset", "rawDesc", "state", "json:"} {
+		if strings.Contains(summary.Content, omitted) {
+			t.Errorf("Expected %q to be left out of the summary:\n%s", omitted, summary.Content)
+		}
+	}
+	if defs := symbolTable.Definitions["Msg.GetName"]; len(defs) != 1 || defs[0].ChunkID != summary.ID {
+		t.Errorf("Expected Msg.GetName to be defined by the summary chunk, got %v", defs)
+	}
+}
+
+func TestGoChunkerCallGraph(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "chunker-calls")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filePath := filepath.Join(tmpDir, "svc", "svc.go")
+	content := []byte(`package svc
+
+type Store struct{}
+
+func (s *Store) Get(id string) string { return id }
+
+func NewStore() *Store { return &Store{} }
+
+type Service struct{}
+
+func (svc *Service) Lookup(id string) string { return svc.helper(id) }
+
+func (svc *Service) helper(id string) string { return id }
+
+func Run() {
+	store := NewStore()
+	store.Get("a")
+	var s Service
+	s.Lookup("b")
+	f := format
+	f("c")
+	_ = Store{}
+}
+
+func format(v string) string { return v }
+
+func Mention() *Store { return nil }
+`)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for _, typeCheck := range []bool{false, true} {
+		chunkerImpl := chunker.NewGoChunker()
+		chunkerImpl.TypeCheck = typeCheck
+		symbolTable := model.NewSymbolTable()
+
+		chunks, err := chunkerImpl.Chunk(filePath, content, symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+
+		bySymbol := make(map[string]model.Chunk)
+		names := make(map[string]string)
+		for _, chunk := range chunks {
+			bySymbol[chunk.Symbols[0]] = chunk
+			names[chunk.ID] = chunk.Symbols[0]
+		}
+		symbolNames := func(ids []string) []string {
+			var result []string
+			for _, id := range ids {
+				result = append(result, names[id])
+			}
+			sort.Strings(result)
+			return result
+		}
+
+		calls, _ := symbolTable.CallGraph(bySymbol["Run"].ID)
+		expected := []string{"NewStore", "Service.Lookup", "Store.Get", "format"}
+		if got := symbolNames(calls); !reflect.DeepEqual(got, expected) {
+			t.Errorf("TypeCheck=%v: expected Run to call %v, got %v", typeCheck, expected, got)
+		}
+
+		_, calledBy := symbolTable.CallGraph(bySymbol["Service.helper"].ID)
+		if got := symbolNames(calledBy); !reflect.DeepEqual(got, []string{"Service.Lookup"}) {
+			t.Errorf("TypeCheck=%v: expected Service.helper to be called by Service.Lookup, got %v", typeCheck, got)
+		}
+
+		if calls, _ := symbolTable.CallGraph(bySymbol["Mention"].ID); len(calls) != 0 {
+			t.Errorf("TypeCheck=%v: expected a type mention not to be a call, got %v", typeCheck, symbolNames(calls))
+		}
+	}
+}
+
+func TestGoChunkerPackageSummary(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "chunker-package")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"store/doc.go": "// Package store keeps things.\npackage store\n",
+		"store/store.go": `package store
+
+// Store keeps things
+type Store struct {
+	Name  string ` + "`json:\"name\"`" + `
+	items map[string]string
+}
+
+// Get returns a thing
+func (s *Store) Get(key string) string {
+	return s.items[key]
+}
+
+func helper() {}
+`,
+		"store/store_test.go": "package store\n\nfunc TestGet() {}\n",
+		"store/local.go":      "package store\n\n// Local is left out by .gitignore\nfunc Local() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	chunkerImpl := chunker.NewGoChunker()
+	symbolTable := model.NewSymbolTable()
+
+	var summaries []model.Chunk
+	var getChunk model.Chunk
+	options := chunker.ChunkingOptions{
+		MaxChunkSize: 50,
+		Ignored: func(path string) bool {
+			return path == filepath.Join(tmpDir, "store/local.go")
+		},
+	}
+	for _, name := range []string{"store/store_test.go", "store/store.go", "store/doc.go"} {
+		chunks, err := chunkerImpl.Chunk(filepath.Join(tmpDir, name), []byte(files[name]), symbolTable, options)
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+			if chunk.Kind == "package" {
+				summaries = append(summaries, chunk)
+			}
+			if len(chunk.Symbols) > 0 && chunk.Symbols[0] == "Store.Get" {
+				getChunk = chunk
+			}
+		}
+	}
+
+	if len(summaries) != 1 {
+		t.Fatalf("Expected one summary chunk for the package, got %d", len(summaries))
+	}
+	summary := summaries[0]
+
+	expected := `// Package store keeps things.
+package store
+
+type Store struct {
+	Name string
+}
+
+func (s *Store) Get(key string) string
+`
+	if summary.Content != expected {
+		t.Errorf("Unexpected package summary:\n%s", summary.Content)
+	}
+	if summary.FilePath != filepath.Join(tmpDir, "store/doc.go") {
+		t.Errorf("Expected the summary to be attributed to doc.go, got %s", summary.FilePath)
+	}
+	if summary.StartLine != 1 || summary.EndLine != 2 {
+		t.Errorf("Expected the summary at the package doc and clause (lines 1-2), got lines %d-%d", summary.StartLine, summary.EndLine)
+	}
+
+	related := false
+	for _, id := range symbolTable.FindRelatedChunks(getChunk) {
+		related = related || id == summary.ID
+	}
+	if !related {
+		t.Error("Expected Store.Get to be related to its package summary")
+	}
+}
+
+func TestGoChunkerEmbedLinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "chunker-embed")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"web/web.go": `package web
+
+import "embed"
+
+//go:embed templates/*
+var templates embed.FS
+
+var (
+	//go:embed "queries/get user.sql" static
+	queries embed.FS
+
+	unrelated = 1
+)
+`,
+		"web/templates/index.tmpl":   "{{ define \"index\" }}hello{{ end }}\n",
+		"web/queries/get user.sql":   "SELECT * FROM users;\n",
+		"web/static/app.css":         "body {}\n",
+		"web/static/_draft/skip.css": "p {}\n",
+		"web/static/.hidden":         "x\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	symbolTable := model.NewSymbolTable()
+	goPath := filepath.Join(tmpDir, "web/web.go")
+	chunks, err := chunker.NewGoChunker().Chunk(goPath, []byte(files["web/web.go"]), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	var templatesVar, queriesVar model.Chunk
+	for _, chunk := range chunks {
+		symbolTable.AddChunk(chunk)
+		if slices.Contains(chunk.Symbols, "templates") {
+			templatesVar = chunk
+		}
+		if slices.Contains(chunk.Symbols, "queries") {
+			queriesVar = chunk
+		}
+	}
+
+	embedded := make(map[string]string)
+	for _, name := range []string{"web/templates/index.tmpl", "web/queries/get user.sql", "web/static/app.css"} {
+		fileChunks, err := chunker.NewGenericChunker().Chunk(filepath.Join(tmpDir, name), []byte(files[name]), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range fileChunks {
+			symbolTable.AddChunk(chunk)
+			embedded[name] = chunk.ID
+		}
+	}
+
+	for _, skipped := range []string{"web/static/_draft/skip.css", "web/static/.hidden"} {
+		if len(symbolTable.FileReferences[filepath.Join(tmpDir, skipped)]) != 0 {
+			t.Errorf("Expected %s to be excluded from the embedded directory", skipped)
+		}
+	}
+
+	tests := []struct {
+		variable model.Chunk
+		file     string
+	}{
+		{templatesVar, "web/templates/index.tmpl"},
+		{queriesVar, "web/queries/get user.sql"},
+		{queriesVar, "web/static/app.css"},
+	}
+	for _, tt := range tests {
+		if !slices.Contains(symbolTable.FindRelatedChunks(tt.variable), embedded[tt.file]) {
+			t.Errorf("Expected %v to be related to %s", tt.variable.Symbols, tt.file)
+		}
+	}
+	if slices.Contains(symbolTable.FindRelatedChunks(templatesVar), embedded["web/static/app.css"]) {
+		t.Error("Expected templates not to be related to files it doesn't embed")
+	}
+}
+
+func TestGoChunkerSyntaxErrors(t *testing.T) {
+	content := `package p
+
+import "fmt"
+
+func good() { fmt.Println() }
+
+// broken is unfinished
+func broken() {
+	x :=
+}
+
+type T struct {
+	A int
+	B ?
+}
+
+var ok = 1
+
+func tail() {
+	if x {
+}
+
+func last() {}
+`
+
+	symbolTable := model.NewSymbolTable()
+	chunks, err := chunker.NewGoChunker().Chunk("p/p.go", []byte(content), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// Declarations around the errors keep their structure
+	symbols := make(map[string]bool)
+	var broken [][2]int
+	for _, chunk := range chunks {
+		for _, symbol := range chunk.Symbols {
+			symbols[symbol] = true
+		}
+		if chunk.Metadata["parse_error"] != "" {
+			if len(chunk.Symbols) > 0 {
+				t.Errorf("Expected no symbols in broken regions, got %v", chunk.Symbols)
+			}
+			broken = append(broken, [2]int{chunk.StartLine, chunk.EndLine})
+		}
+	}
+	for _, symbol := range []string{"good", "ok", "last"} {
+		if !symbols[symbol] {
+			t.Errorf("Expected a chunk for %s, got %v", symbol, symbols)
+		}
+	}
+
+	expected := [][2]int{{7, 10}, {12, 15}, {19, 21}}
+	if !reflect.DeepEqual(broken, expected) {
+		t.Errorf("Expected broken regions %v, got %v", expected, broken)
+	}
+
+	if len(symbolTable.Diagnostics) != 3 {
+		t.Fatalf("Expected 3 diagnostics, got %v", symbolTable.Diagnostics)
+	}
+	if diagnostic := symbolTable.Diagnostics[0]; diagnostic.FilePath != "p/p.go" || diagnostic.Line != 10 {
+		t.Errorf("Unexpected diagnostic %+v", diagnostic)
+	}
+
+	// Without a package clause nothing can be parsed
+	symbolTable = model.NewSymbolTable()
+	chunks, err = chunker.NewGoChunker().Chunk("q.go", []byte("packag q\n\nfunc f() {}\n"), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Metadata["parse_error"] == "" || len(symbolTable.Diagnostics) != 1 {
+		t.Errorf("Expected the whole file as one broken chunk, got %+v", chunks)
+	}
+}
+
+func TestGoChunkerPreciseImports(t *testing.T) {
+	content := `package p
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	_ "embed"
+	str "strings"
+)
+
+func helper(s string) string {
+	return str.TrimSpace(s)
+}
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, r.URL)
+}
+
+func shadow() {
+	fmt := "not the package"
+	_ = fmt.Sprint
+}
+
+var db *sql.DB
+
+func long(w http.ResponseWriter) {
+	a := 1
+	b := 2
+	c := 3
+	fmt.Println(a, b, c)
+	d := 4
+	e := 5
+	f := 6
+	_ = str.Repeat("x", d+e+f)
+}
+`
+
+	chunks, err := chunker.NewGoChunker().Chunk("p.go", []byte(content), model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 6})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	expected := map[string][]string{
+		"helper":     {"strings"},
+		"handle":     {"fmt", "net/http"},
+		"shadow":     nil,
+		"db":         {"database/sql"},
+		"long":       {"fmt", "net/http"},
+		"long#part2": {"net/http", "strings"},
+	}
+	for _, chunk := range chunks {
+		if len(chunk.Symbols) == 0 {
+			continue
+		}
+		want, ok := expected[chunk.Symbols[0]]
+		if !ok {
+			continue
+		}
+		delete(expected, chunk.Symbols[0])
+		if !reflect.DeepEqual(chunk.Imports, want) {
+			t.Errorf("Expected %s to import %v, got %v", chunk.Symbols[0], want, chunk.Imports)
+		}
+	}
+	if len(expected) != 0 {
+		t.Errorf("Missing chunks for %v", expected)
+	}
+}
+
+func TestGoChunkerVersionedImports(t *testing.T) {
+	content := `package p
+
+import (
+	"math/rand/v2"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sabhiram/go-gitignore"
+	"gopkg.in/yaml.v3"
+)
+
+func decode(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
+
+func connect() (*pgx.Conn, error) {
+	return pgx.Connect(nil, "")
+}
+
+func pick() int {
+	return rand.N(10)
+}
+
+func ignore() {
+	gitignore.CompileIgnoreLines("*.tmp")
+}
+`
+
+	expected := map[string][]string{
+		"decode":  {"gopkg.in/yaml.v3"},
+		"connect": {"github.com/jackc/pgx/v5"},
+		"pick":    {"math/rand/v2"},
+		"ignore":  {"github.com/sabhiram/go-gitignore"},
+	}
+	for _, typeCheck := range []bool{false, true} {
+		goChunker := chunker.NewGoChunker()
+		goChunker.TypeCheck = typeCheck
+		chunks, err := goChunker.Chunk("p.go", []byte(content), model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			if len(chunk.Symbols) == 0 {
+				continue
+			}
+			if want, ok := expected[chunk.Symbols[0]]; ok && !reflect.DeepEqual(chunk.Imports, want) {
+				t.Errorf("TypeCheck=%v: expected %s to import %v, got %v", typeCheck, chunk.Symbols[0], want, chunk.Imports)
+			}
+		}
+	}
+}
+
+func TestGoChunkerMessages(t *testing.T) {
+	content := `package p
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+var ErrClosed = errors.New("store is closed")
+
+func open(name string, retries int) error {
+	if retries > 3 {
+		panic(fmt.Sprintf("too many retries (%d) for %q", retries, name))
+	}
+	log.Printf("opening %s at 100%%", name)
+	slog.InfoContext(nil, "store opened", "name", name)
+	slog.Info(name, "key", "value")
+	panic("unreachable: " + name)
+	return fmt.Errorf("open %[1]s: %w", name, ErrClosed)
+}
+
+func retry(name string) {
+	log.Printf("opening %s at 100%%", name)
+	log.Printf("opening %s at 100%%", name)
+}
+
+func quiet() error {
+	msg := fmt.Sprintf("not an error message %d", 1)
+	return fmt.Errorf("%w", errors.New(msg))
+}
+`
+
+	chunks, err := chunker.NewGoChunker().Chunk("p.go", []byte(content), model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	// * stands for the wildcard in the expected templates
+	w := func(template string) string {
+		return strings.ReplaceAll(template, "*", model.MessageWildcard)
+	}
+	expected := map[string][]model.Message{
+		"ErrClosed": {{Template: "store is closed", Lines: []int{10}}},
+		"open": {
+			{Template: w("too many retries (*) for *"), Lines: []int{14}},
+			{Template: w("opening * at 100%"), Lines: []int{16}},
+			{Template: "store opened", Lines: []int{17}},
+			{Template: w("unreachable: *"), Lines: []int{19}},
+			{Template: w("open *: *"), Lines: []int{20}},
+		},
+		"retry": {{Template: w("opening * at 100%"), Lines: []int{24, 25}}},
+		"quiet": nil,
+	}
+	for _, chunk := range chunks {
+		if len(chunk.Symbols) == 0 {
+			continue
+		}
+		if want, ok := expected[chunk.Symbols[0]]; ok && !reflect.DeepEqual(chunk.Messages, want) {
+			t.Errorf("Expected %s to produce %v, got %v", chunk.Symbols[0], want, chunk.Messages)
+		}
+	}
+}
+
+func TestGoChunkerLoggerMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "server.go")
+	content := []byte(`package server
+
+import (
+	"log"
+	"log/slog"
+)
+
+type server struct {
+	logger *log.Logger
+	*slog.Logger
+}
+
+func (s *server) start(port int, pattern string) {
+	s.logger.Printf("listening on :%d", port)
+	s.Info("server started", "port", port)
+	s.Logger.WarnContext(nil, "slow start")
+	logger := slog.Default()
+	logger.Error("glob * matched " + pattern)
+}
+`)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for _, typeCheck := range []bool{false, true} {
+		chunkerImpl := chunker.NewGoChunker()
+		chunkerImpl.TypeCheck = typeCheck
+		chunks, err := chunkerImpl.Chunk(filePath, content, model.NewSymbolTable(), chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+
+		// Logger methods are only known through the receiver's type, and a
+		// literal * isn't a wildcard
+		var expected []model.Message
+		if typeCheck {
+			expected = []model.Message{
+				{Template: "listening on :" + model.MessageWildcard, Lines: []int{14}},
+				{Template: "server started", Lines: []int{15}},
+				{Template: "slow start", Lines: []int{16}},
+				{Template: "glob * matched " + model.MessageWildcard, Lines: []int{18}},
+			}
+		}
+		for _, chunk := range chunks {
+			if len(chunk.Symbols) > 0 && chunk.Symbols[0] == "server.start" && !reflect.DeepEqual(chunk.Messages, expected) {
+				t.Errorf("TypeCheck=%v: expected start to produce %q, got %q", typeCheck, expected, chunk.Messages)
+			}
+		}
+	}
+}
+
+func TestGoChunkerCommands(t *testing.T) {
+	content := `package cli
+
+import (
+	"flag"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"example.com/tool/config"
+)
+
+const defaultMaxSize = 2 * 1024
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [addr]",
+	Short: "Serve the index",
+	RunE:  runServe,
+}
+
+func init() {
+	serveCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
+}
+
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tool",
+		Short: "Tool does things",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRoot(args)
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&dir, "dir", "d", ".", "Directory to process")
+	cmd.Flags().Bool("verbose", false, "Print more")
+	cmd.Flags().StringSliceP("exclude", "e", nil, "Patterns to skip")
+	cmd.Flags().String("generated", config.GeneratedKeep, "What to do with generated files")
+	cmd.Flags().Int("max-size", defaultMaxSize, "Maximum chunk size")
+	cmd.Flags().Duration("timeout", 30*time.Second, "Give up after this long")
+	cmd.Flags().Float64("overlap", 0.25, "Overlap between chunks")
+	cmd.AddCommand(serveCmd)
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error { return nil }
+
+func runRoot(args []string) error { return nil }
+
+func parseLegacy(args []string) {
+	fs := flag.NewFlagSet("legacy", flag.ExitOnError)
+	fs.Duration("timeout", 0, "Give up after this long")
+	fs.Int64("retries", 3, "Attempts before giving up")
+	fs.Parse(args)
+}
+`
+
+	symbolTable := model.NewSymbolTable()
+	chunks, err := chunker.NewGoChunker().Chunk("cli/cli.go", []byte(content), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	commands := make(map[string]model.Chunk)
+	symbols := make(map[string]string)
+	for _, chunk := range chunks {
+		symbolTable.AddChunk(chunk)
+		if chunk.Kind == "command" {
+			commands[chunk.Command.Name] = chunk
+		}
+		if len(chunk.Symbols) > 0 {
+			symbols[chunk.ID] = chunk.Symbols[0]
+		}
+	}
+
+	root, ok := commands["tool"]
+	if !ok {
+		t.Fatalf("Expected a command chunk for the root command, got %v", commands)
+	}
+	expected := model.Command{
+		Name:        "tool",
+		Use:         "tool",
+		Short:       "Tool does things",
+		Subcommands: []string{"serve"},
+		Flags: []model.CommandFlag{
+			{Name: "dir", Shorthand: "d", Type: "string", Default: ".", Usage: "Directory to process", Persistent: true},
+			{Name: "verbose", Type: "bool", Usage: "Print more"},
+			{Name: "exclude", Shorthand: "e", Type: "strings", Usage: "Patterns to skip"},
+			{Name: "generated", Type: "string", Usage: "What to do with generated files"},
+			{Name: "max-size", Type: "int", Default: "2048", Usage: "Maximum chunk size"},
+			{Name: "timeout", Type: "duration", Default: "30s", Usage: "Give up after this long"},
+			{Name: "overlap", Type: "float", Default: "0.25", Usage: "Overlap between chunks"},
+		},
+	}
+	if !reflect.DeepEqual(*root.Command, expected) {
+		t.Errorf("Unexpected root command %+v", *root.Command)
+	}
+	if !strings.Contains(root.Content, "-d, --dir string") || !strings.Contains(root.Content, `(default ".")`) ||
+		!strings.Contains(root.Content, "-e, --exclude strings") || !strings.Contains(root.Content, "--verbose") {
+		t.Errorf("Expected the flags in the help text, got:\n%s", root.Content)
+	}
+
+	serve := commands["serve"]
+	if serve.Command == nil || len(serve.Command.Flags) != 1 || serve.Command.Flags[0] != (model.CommandFlag{Name: "port", Shorthand: "p", Type: "int", Default: "8080", Usage: "Port to listen on"}) {
+		t.Errorf("Expected the serve command with its port flag, got %+v", serve.Command)
+	}
+	legacy := commands["legacy"]
+	if legacy.Command == nil || len(legacy.Command.Flags) != 2 || legacy.Command.Flags[0].Type != "duration" ||
+		legacy.Command.Flags[1] != (model.CommandFlag{Name: "retries", Type: "int", Default: "3", Usage: "Attempts before giving up"}) {
+		t.Errorf("Expected the flag set as a command, got %+v", legacy.Command)
+	}
+
+	// Commands relate to the code they run and to their subcommands
+	tests := []struct {
+		command model.Chunk
+		related []string
+	}{
+		{root, []string{"runRoot", "NewRootCommand", "command:serve"}},
+		{serve, []string{"runServe", "command:tool"}},
+	}
+	for _, tt := range tests {
+		related := make(map[string]bool)
+		for _, id := range symbolTable.FindRelatedChunks(tt.command) {
+			related[symbols[id]] = true
+		}
+		for _, symbol := range tt.related {
+			if !related[symbol] {
+				t.Errorf("Expected %s to be related to %s, got %v", tt.command.Symbols[0], symbol, related)
+			}
+		}
+	}
+}
+
+func TestGoChunkerCommandDefaultsFromPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module example.com/tool\n",
+		"chunker/policy.go": `package chunker
+
+const (
+	GeneratedKeep = "keep"
+	GeneratedSkip = "skip"
+)
+
+var DefaultSplitPaths = []string{"project/dependency", "ItemGroup"}
+`,
+		"cmd/limits.go": "package cmd\n\nconst defaultWorkers = 2 * parallelism\n\nconst parallelism = 4\n",
+		"cmd/root.go": `package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"example.com/tool/chunker"
+)
+
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "tool"}
+	cmd.PersistentFlags().String("generated", chunker.GeneratedKeep, "Policy for generated files")
+	cmd.PersistentFlags().StringSlice("split-paths", chunker.DefaultSplitPaths, "Paths to split on")
+	cmd.Flags().Int("workers", defaultWorkers, "Number of workers")
+	return cmd
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	path := filepath.Join(tmpDir, "cmd", "root.go")
+	chunks, err := chunker.NewGoChunker().Chunk(path, []byte(files["cmd/root.go"]), model.NewSymbolTable(), chunker.ChunkingOptions{RootDir: tmpDir, MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+
+	var root *model.Chunk
+	for i := range chunks {
+		if chunks[i].Kind == "command" {
+			root = &chunks[i]
+		}
+	}
+	if root == nil {
+		t.Fatal("Expected a command chunk")
+	}
+	expected := []model.CommandFlag{
+		{Name: "generated", Type: "string", Default: "keep", Usage: "Policy for generated files", Persistent: true},
+		{Name: "split-paths", Type: "strings", Default: "[project/dependency,ItemGroup]", Usage: "Paths to split on", Persistent: true},
+		{Name: "workers", Type: "int", Default: "8", Usage: "Number of workers"},
+	}
+	if !reflect.DeepEqual(root.Command.Flags, expected) {
+		t.Errorf("Expected flags %+v, got %+v", expected, root.Command.Flags)
+	}
+	if !strings.Contains(root.Content, `(default "keep")`) {
+		t.Errorf("Expected the default from the other package in the help text, got:\n%s", root.Content)
+	}
+}
+
+func TestGoChunkerConfigSchema(t *testing.T) {
+	goContent := `package config
+
+type Config struct {
+	Server   ServerConfig ` + "`yaml:\"server\" json:\"server\" toml:\"server\"`" + `
+	LogLevel string       ` + "`yaml:\"log_level\" env:\"LOG_LEVEL\"`" + `
+}
+
+type ServerConfig struct {
+	Port    int ` + "`yaml:\"port\" json:\"port\" toml:\"port\" env:\"PORT,required\"`" + `
+	ignored int
+}
+
+type Unrelated struct {
+	Name string
+}
+`
+
+	symbolTable := model.NewSymbolTable()
+	goChunks, err := chunker.NewGoChunker().Chunk("config/config.go", []byte(goContent), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+	if err != nil {
+		t.Fatalf("Chunker.Chunk() error = %v", err)
+	}
+	structs := make(map[string]model.Chunk)
+	for _, chunk := range goChunks {
+		symbolTable.AddChunk(chunk)
+		if len(chunk.Symbols) > 0 {
+			structs[chunk.Symbols[0]] = chunk
+		}
+	}
+
+	expectedSchema := `ServerConfig.Port int yaml:"port" json:"port" toml:"port" env:"PORT,required"`
+	if schema := structs["ServerConfig"].Metadata["schema"]; schema != expectedSchema {
+		t.Errorf("Expected schema %q, got %q", expectedSchema, schema)
+	}
+	if _, ok := structs["Unrelated"].Metadata["schema"]; ok {
+		t.Error("Expected no schema for a struct without tags")
+	}
+
+	files := []struct {
+		path    string
+		content string
+		chunker chunker.Chunker
+	}{
+		{"deploy/config.yaml", "server:\n  port: 8080\nlog_level: debug\nother:\n  port: 1\n", chunker.NewGenericChunker()},
+		{"deploy/config.json", "{\n  \"server\": {\n    \"port\": 8080\n  }\n}\n", chunker.NewGenericChunker()},
+		{"deploy/app.toml", "[server]\nport = 8080\n", chunker.NewConfigChunker()},
+		{"deploy/Dockerfile", "FROM alpine\nENV PORT=8080 \\\n    LOG_LEVEL=info\n", chunker.NewDockerfileChunker()},
+	}
+	consumers := make(map[string][]string)
+	for _, file := range files {
+		chunks, err := file.chunker.Chunk(file.path, []byte(file.content), symbolTable, chunker.ChunkingOptions{MaxChunkSize: 50})
+		if err != nil {
+			t.Fatalf("Chunker.Chunk() error = %v", err)
+		}
+		for _, chunk := range chunks {
+			symbolTable.AddChunk(chunk)
+		}
+		for _, chunk := range chunks {
+			for _, id := range symbolTable.FindRelatedChunks(chunk) {
+				if related, ok := symbolTable.GetChunk(id); ok && related.FilePath == "config/config.go" {
+					consumers[file.path] = append(consumers[file.path], related.Symbols[0])
+				}
+			}
+		}
+	}
+
+	expected := map[string][]string{
+		"deploy/config.yaml": {"Config", "ServerConfig"},
+		"deploy/config.json": {"Config", "ServerConfig"},
+		"deploy/app.toml":    {"Config", "ServerConfig"},
+		"deploy/Dockerfile":  {"Config", "ServerConfig"},
+	}
+	for path, want := range expected {
+		got := consumers[path]
+		sort.Strings(got)
+		got = slices.Compact(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %s to be consumed by %v, got %v", path, want, got)
+		}
+	}
+
+	// The struct relates back to the files setting its keys
+	related := make(map[string]bool)
+	for _, id := range symbolTable.FindRelatedChunks(structs["ServerConfig"]) {
+		if chunk, ok := symbolTable.GetChunk(id); ok {
+			related[chunk.FilePath] = true
+		}
+	}
+	for _, file := range files {
+		if !related[file.path] {
+			t.Errorf("Expected ServerConfig to be related to %s", file.path)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package chunker
+
+import (
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// CMakeChunker implements the Chunker interface for CMakeLists.txt and .cmake files
+type CMakeChunker struct{}
+
+// NewCMakeChunker creates a new CMake chunker
+func NewCMakeChunker() *CMakeChunker {
+	return &CMakeChunker{}
+}
+
+// cmakeCommand is a single command invocation
+type cmakeCommand struct {
+	name      string // lower-cased, CMake command names are case-insensitive
+	args      []string
+	startLine int // 1-based, including comments directly above the command
+	endLine   int
+}
+
+// cmakeLinkKeywords are the target_link_libraries arguments that aren't targets
+var cmakeLinkKeywords = map[string]bool{
+	"PUBLIC":                   true,
+	"PRIVATE":                  true,
+	"INTERFACE":                true,
+	"LINK_PUBLIC":              true,
+	"LINK_PRIVATE":             true,
+	"LINK_INTERFACE_LIBRARIES": true,
+	"debug":                    true,
+	"optimized":                true,
+	"general":                  true,
+}
+
+// Language returns the language this chunker supports
+func (c *CMakeChunker) Language() string {
+	return "cmake"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *CMakeChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "cmake"
+}
+
+// cmakeUnit is a group of commands that is chunked together
+type cmakeUnit struct {
+	kind     string // "function", "macro", "target" or "" for other commands
+	commands []cmakeCommand
+}
+
+// Chunk splits a CMake file into function/macro definitions, targets and the remaining commands
+func (c *CMakeChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	lines := strings.Split(string(content), "\n")
+	commands := parseCMakeCommands(string(content))
+
+	// Group the commands into units. A target unit collects the target_* calls
+	// that follow its add_library/add_executable anywhere in the file
+	var units []*cmakeUnit
+	targetUnits := make(map[string]*cmakeUnit)
+
+	for i := 0; i < len(commands); i++ {
+		cmd := commands[i]
+
+		switch {
+		case cmd.name == "function" || cmd.name == "macro":
+			// A definition runs to its matching end command, including nested definitions
+			end := i
+			depth := 0
+			for ; end < len(commands); end++ {
+				switch commands[end].name {
+				case cmd.name:
+					depth++
+				case "end" + cmd.name:
+					depth--
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if end == len(commands) {
+				end--
+			}
+			units = append(units, &cmakeUnit{kind: cmd.name, commands: commands[i : end+1]})
+			i = end
+
+		case (cmd.name == "add_library" || cmd.name == "add_executable") && len(cmd.args) > 0:
+			unit := &cmakeUnit{kind: "target", commands: []cmakeCommand{cmd}}
+			targetUnits[cmd.args[0]] = unit
+			units = append(units, unit)
+
+		case strings.HasPrefix(cmd.name, "target_") && len(cmd.args) > 0 && targetUnits[cmd.args[0]] != nil:
+			unit := targetUnits[cmd.args[0]]
+			unit.commands = append(unit.commands, cmd)
+
+		default:
+			units = append(units, &cmakeUnit{commands: []cmakeCommand{cmd}})
+		}
+	}
+
+	var chunks []model.Chunk
+	var pending []cmakeCommand
+
+	// flushPending emits the ungrouped commands, split at command boundaries up to MaxChunkSize
+	flushPending := func() {
+		for len(pending) > 0 {
+			n := 1
+			for n < len(pending) && pending[n].endLine-pending[0].startLine+1 <= options.MaxChunkSize {
+				n++
+			}
+			chunks = append(chunks, c.createChunk(filePath, lines, pending[:n], nil))
+			pending = pending[n:]
+		}
+	}
+
+	for _, unit := range units {
+		if unit.kind == "" {
+			pending = append(pending, unit.commands...)
+			continue
+		}
+		flushPending()
+
+		first := unit.commands[0]
+		if len(first.args) == 0 {
+			chunks = append(chunks, c.createChunk(filePath, lines, unit.commands, nil))
+			continue
+		}
+
+		name := first.args[0]
+		chunk := c.createChunk(filePath, lines, unit.commands, []string{name})
+		c.addDefinition(symbolTable, chunk, name, unit.kind)
+
+		if unit.kind == "target" {
+			// add_library(alias ALIAS target) depends on the aliased target
+			if len(first.args) > 2 && first.args[1] == "ALIAS" {
+				c.addReference(symbolTable, chunk, first.args[2], first.startLine)
+			}
+			for _, cmd := range unit.commands {
+				if cmd.name != "target_link_libraries" {
+					continue
+				}
+				for _, dep := range cmd.args[1:] {
+					if isCMakeTargetName(dep) {
+						c.addReference(symbolTable, chunk, dep, cmd.startLine)
+					}
+				}
+			}
+		}
+
+		chunks = append(chunks, chunk)
+	}
+	flushPending()
+
+	return chunks, nil
+}
+
+// createChunk creates a chunk from a group of commands. Commands separated by
+// other code (a target and its later target_* calls) are joined with a blank line
+func (c *CMakeChunker) createChunk(filePath string, lines []string, commands []cmakeCommand, symbols []string) model.Chunk {
+	var parts []string
+	partStart := commands[0].startLine
+	partEnd := commands[0].endLine
+
+	for _, cmd := range commands[1:] {
+		if cmd.startLine <= partEnd || isCMakeGap(lines[partEnd:cmd.startLine-1]) {
+			if cmd.endLine > partEnd {
+				partEnd = cmd.endLine
+			}
+			continue
+		}
+		parts = append(parts, strings.Join(lines[partStart-1:partEnd], "\n"))
+		partStart, partEnd = cmd.startLine, cmd.endLine
+	}
+	parts = append(parts, strings.Join(lines[partStart-1:partEnd], "\n"))
+
+	content := strings.Join(parts, "\n\n")
+	startLine := commands[0].startLine
+	endLine := commands[len(commands)-1].endLine
+
+	return model.Chunk{
+		ID:         util.GenerateID(filePath, content),
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   "cmake",
+		Symbols:    symbols,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+}
+
+// isCMakeGap checks if the lines between two commands hold only blank lines and comments
+func isCMakeGap(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// addDefinition records a symbol defined by a chunk
+func (c *CMakeChunker) addDefinition(symbolTable *model.SymbolTable, chunk model.Chunk, name string, defType string) {
+	symbolTable.AddDefinition(name, model.SymbolDefinition{
+		Name:      name,
+		ChunkID:   chunk.ID,
+		FilePath:  chunk.FilePath,
+		StartLine: chunk.StartLine,
+		EndLine:   chunk.EndLine,
+		Type:      defType,
+	})
+}
+
+// addReference records a symbol used by a chunk
+func (c *CMakeChunker) addReference(symbolTable *model.SymbolTable, chunk model.Chunk, name string, line int) {
+	symbolTable.AddReference(name, model.SymbolReference{
+		Name:     name,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+	})
+}
+
+// isCMakeTargetName checks if a target_link_libraries argument names a target
+// rather than a keyword, variable, generator expression or linker flag
+func isCMakeTargetName(arg string) bool {
+	return arg != "" && !cmakeLinkKeywords[arg] &&
+		!strings.Contains(arg, "${") && !strings.Contains(arg, "$<") &&
+		!strings.HasPrefix(arg, "-") && !strings.Contains(arg, "/")
+}
+
+// parseCMakeCommands parses the command invocations of a CMake file, handling
+// quoted and bracket arguments, line and bracket comments and nested parentheses
+func parseCMakeCommands(content string) []cmakeCommand {
+	var commands []cmakeCommand
+	line := 1
+	commentStart := 0 // first line of the comment block directly above the next command
+	lastLine := 0     // last line holding code or a comment
+
+	for i := 0; i < len(content); {
+		ch := content[i]
+
+		switch {
+		case ch == '\n':
+			line++
+			i++
+			continue
+
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+			continue
+
+		case ch == '#':
+			if commentStart == 0 || lastLine < line-1 {
+				commentStart = line
+			}
+			if length := cmakeBracketLength(content[i+1:]); length > 0 {
+				end := cmakeBracketEnd(content, i+1, length)
+				line += strings.Count(content[i:end], "\n")
+				i = end
+			} else {
+				for i < len(content) && content[i] != '\n' {
+					i++
+				}
+			}
+			lastLine = line
+			continue
+
+		case isCMakeIdentStart(ch):
+			start := i
+			for i < len(content) && isCMakeIdentChar(content[i]) {
+				i++
+			}
+			name := strings.ToLower(content[start:i])
+
+			for i < len(content) && (content[i] == ' ' || content[i] == '\t') {
+				i++
+			}
+			if i >= len(content) || content[i] != '(' {
+				continue
+			}
+
+			cmdStart := line
+			if commentStart > 0 && lastLine >= line-1 {
+				cmdStart = commentStart
+			}
+			// A comment after the previous command, on its last line, belongs to it
+			if n := len(commands); n > 0 && cmdStart <= commands[n-1].endLine {
+				cmdStart = min(commands[n-1].endLine+1, line)
+			}
+
+			args, end, newlines := parseCMakeArguments(content, i+1)
+			commands = append(commands, cmakeCommand{
+				name:      name,
+				args:      args,
+				startLine: cmdStart,
+				endLine:   line + newlines,
+			})
+			line += newlines
+			lastLine = line
+			commentStart = 0
+			i = end
+			continue
+		}
+
+		i++
+	}
+
+	return commands
+}
+
+// parseCMakeArguments parses the arguments of a command starting just after its
+// opening parenthesis. It returns the arguments, the offset after the closing
+// parenthesis and the number of newlines consumed
+func parseCMakeArguments(content string, i int) ([]string, int, int) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	depth := 1
+	newlines := 0
+
+	flush := func() {
+		if inArg {
+			args = append(args, current.String())
+			current.Reset()
+			inArg = false
+		}
+	}
+
+	for i < len(content) {
+		ch := content[i]
+
+		switch {
+		case ch == '\n':
+			newlines++
+			flush()
+			i++
+
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			flush()
+			i++
+
+		case ch == '#':
+			flush()
+			if length := cmakeBracketLength(content[i+1:]); length > 0 {
+				end := cmakeBracketEnd(content, i+1, length)
+				newlines += strings.Count(content[i:end], "\n")
+				i = end
+			} else {
+				for i < len(content) && content[i] != '\n' {
+					i++
+				}
+			}
+
+		case ch == '"':
+			inArg = true
+			i++
+			for i < len(content) && content[i] != '"' {
+				if content[i] == '\\' && i+1 < len(content) {
+					i++
+				}
+				if content[i] == '\n' {
+					newlines++
+				}
+				current.WriteByte(content[i])
+				i++
+			}
+			i++
+
+		case ch == '[' && cmakeBracketLength(content[i:]) > 0:
+			length := cmakeBracketLength(content[i:])
+			end := cmakeBracketEnd(content, i, length)
+			inArg = true
+			bodyEnd := end - length
+			if bodyEnd < i+length {
+				bodyEnd = i + length
+			}
+			current.WriteString(content[i+length : bodyEnd])
+			newlines += strings.Count(content[i:end], "\n")
+			i = end
+
+		case ch == '(':
+			depth++
+			inArg = true
+			current.WriteByte(ch)
+			i++
+
+		case ch == ')':
+			depth--
+			if depth == 0 {
+				flush()
+				return args, i + 1, newlines
+			}
+			current.WriteByte(ch)
+			i++
+
+		default:
+			inArg = true
+			current.WriteByte(ch)
+			i++
+		}
+	}
+
+	flush()
+	return args, i, newlines
+}
+
+// cmakeBracketLength returns the length of a bracket opener like [[ or [==[ at the start of s, or 0
+func cmakeBracketLength(s string) int {
+	if !strings.HasPrefix(s, "[") {
+		return 0
+	}
+	n := 1
+	for n < len(s) && s[n] == '=' {
+		n++
+	}
+	if n < len(s) && s[n] == '[' {
+		return n + 1
+	}
+	return 0
+}
+
+// cmakeBracketEnd returns the offset just after the bracket closer matching the opener at start
+func cmakeBracketEnd(content string, start int, length int) int {
+	closer := "]" + strings.Repeat("=", length-2) + "]"
+	idx := strings.Index(content[start+length:], closer)
+	if idx < 0 {
+		return len(content)
+	}
+	return start + length + idx + len(closer)
+}
+
+// isCMakeIdentStart checks if a byte can start a command name
+func isCMakeIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// isCMakeIdentChar checks if a byte can appear in a command name
+func isCMakeIdentChar(ch byte) bool {
+	return isCMakeIdentStart(ch) || (ch >= '0' && ch <= '9')
+}
@@ -0,0 +1,369 @@
+package chunker
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// composeSections are the top-level compose keys whose entries each get their own chunk
+var composeSections = []string{"services", "networks", "volumes", "secrets"}
+
+// ComposeChunker implements the Chunker interface for docker-compose files
+type ComposeChunker struct{}
+
+// NewComposeChunker creates a new docker-compose chunker
+func NewComposeChunker() *ComposeChunker {
+	return &ComposeChunker{}
+}
+
+// composeEntry is a service, network, volume or secret with the lines it owns
+type composeEntry struct {
+	section   string
+	name      string
+	node      *yaml.Node
+	startLine int
+	endLine   int
+}
+
+// Language returns the language this chunker supports
+func (c *ComposeChunker) Language() string {
+	return "compose"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *ComposeChunker) CanHandle(filePath string, language string, framework string) bool {
+	return framework == "compose"
+}
+
+// Chunk splits a compose file into one chunk per service, network, volume and
+// secret, split between their keys if they exceed MaxChunkSize. Services
+// reference the Dockerfiles they build, their env files and the services,
+// networks, volumes and secrets they use
+func (c *ComposeChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		// Malformed compose files are still worth indexing as text
+		return NewGenericChunker().Chunk(filePath, content, symbolTable, options)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	root := doc.Content[0]
+
+	// Entries are namespaced by project, which defaults to the directory name like compose itself
+	project := filepath.Base(filepath.Dir(filePath))
+	if name := yamlField(root, "name"); name != nil && name.Kind == yaml.ScalarNode {
+		project = name.Value
+	}
+
+	entries := composeEntries(root, lines)
+
+	covered := make([]bool, len(lines)+2)
+	for _, entry := range entries {
+		for line := entry.startLine; line <= entry.endLine; line++ {
+			covered[line] = true
+		}
+	}
+
+	var chunks []model.Chunk
+	entryIdx := 0
+
+	for line := 1; line <= len(lines); {
+		if entryIdx < len(entries) && entries[entryIdx].startLine == line {
+			entry := entries[entryIdx]
+			chunks = append(chunks, c.createEntryChunks(filePath, lines, project, entry, symbolTable, options)...)
+			entryIdx++
+			line = entry.endLine + 1
+			continue
+		}
+
+		// Top-level keys such as name and x- extensions are chunked by lines
+		if strings.TrimSpace(lines[line-1]) == "" {
+			line++
+			continue
+		}
+		end := line
+		for end+1 <= len(lines) && !covered[end+1] && end-line+1 < options.MaxChunkSize {
+			end++
+		}
+		for strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		chunks = append(chunks, c.createChunk(filePath, lines, line, end, nil))
+		line = end + 1
+	}
+
+	return chunks, nil
+}
+
+// createChunk creates a chunk from a range of lines
+func (c *ComposeChunker) createChunk(filePath string, lines []string, startLine, endLine int, symbols []string) model.Chunk {
+	content := strings.Join(lines[startLine-1:endLine], "\n")
+
+	return model.Chunk{
+		ID:         util.GenerateID(filePath, content),
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   "yaml",
+		Framework:  "compose",
+		Symbols:    symbols,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+}
+
+// createEntryChunks creates the chunks for a compose entry, split between its
+// keys if it exceeds MaxChunkSize, and records what a service uses from the
+// chunk holding each use
+func (c *ComposeChunker) createEntryChunks(filePath string, lines []string, project string, entry composeEntry, symbolTable *model.SymbolTable, options ChunkingOptions) []model.Chunk {
+	symbol := composeSymbol(project, entry.section, entry.name)
+
+	// Keys own the comments directly above them, like entries do
+	section := configSection{startLine: entry.startLine, endLine: entry.endLine}
+	if entry.node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(entry.node.Content); i += 2 {
+			section.entries = append(section.entries, commentedStart(lines, entry.node.Content[i].Line, entry.startLine+1))
+		}
+	}
+
+	var chunks []model.Chunk
+	packSections([]configSection{section}, options, func(_ []configSection, startLine, endLine int) {
+		chunk := c.createChunk(filePath, lines, startLine, endLine, []string{symbol})
+		symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+			Name:      symbol,
+			ChunkID:   chunk.ID,
+			FilePath:  filePath,
+			StartLine: chunk.StartLine,
+			EndLine:   chunk.EndLine,
+			Type:      strings.TrimSuffix(entry.section, "s"),
+		})
+		chunks = append(chunks, chunk)
+	})
+
+	if entry.section != "services" || entry.node.Kind != yaml.MappingNode {
+		return chunks
+	}
+
+	chunkAt := func(line int) model.Chunk {
+		for _, chunk := range chunks {
+			if line <= chunk.EndLine {
+				return chunk
+			}
+		}
+		return chunks[len(chunks)-1]
+	}
+
+	dir := filepath.Dir(filePath)
+	service := entry.node
+
+	if dockerfile, line := composeDockerfile(dir, yamlField(service, "build")); dockerfile != "" {
+		c.addFileReference(symbolTable, chunkAt(line), dockerfile, line)
+	}
+
+	for _, envFile := range composeNames(yamlField(service, "env_file"), "path") {
+		c.addFileReference(symbolTable, chunkAt(envFile.Line), resolveComposePath(dir, envFile.Value), envFile.Line)
+	}
+
+	for _, section := range []string{"services", "networks", "secrets"} {
+		key := section
+		if section == "services" {
+			key = "depends_on"
+		}
+		for _, used := range composeNames(yamlField(service, key), "source") {
+			c.addReference(symbolTable, chunkAt(used.Line), composeSymbol(project, section, used.Value), used.Line)
+		}
+	}
+
+	// Only named volumes are entries; bind mounts start with a path
+	for _, volume := range composeNames(yamlField(service, "volumes"), "source") {
+		source := strings.SplitN(volume.Value, ":", 2)[0]
+		if source == "" || strings.ContainsAny(source[:1], "./~$") {
+			continue
+		}
+		c.addReference(symbolTable, chunkAt(volume.Line), composeSymbol(project, "volumes", source), volume.Line)
+	}
+
+	return chunks
+}
+
+// addReference records a symbol used by a chunk
+func (c *ComposeChunker) addReference(symbolTable *model.SymbolTable, chunk model.Chunk, name string, line int) {
+	symbolTable.AddReference(name, model.SymbolReference{
+		Name:     name,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+	})
+}
+
+// addFileReference records a file used by a chunk
+func (c *ComposeChunker) addFileReference(symbolTable *model.SymbolTable, chunk model.Chunk, path string, line int) {
+	symbolTable.AddFileReference(path, model.SymbolReference{
+		Name:     path,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+	})
+}
+
+// composeEntries returns the entries of the chunked sections in file order. An
+// entry owns the lines up to the next entry or top-level key, and the first
+// entry of a section also owns the section's header
+func composeEntries(root *yaml.Node, lines []string) []composeEntry {
+	var entries []composeEntry
+
+	// Lines where top-level keys start, to end each section's last entry
+	var topLines []int
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		topLines = append(topLines, root.Content[i].Line)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		section := root.Content[i].Value
+		value := root.Content[i+1]
+		if !containsString(composeSections, section) || value.Kind != yaml.MappingNode {
+			continue
+		}
+
+		sectionEnd := len(lines)
+		if i/2+1 < len(topLines) {
+			sectionEnd = commentedStart(lines, topLines[i/2+1], root.Content[i].Line+1) - 1
+		}
+
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			entry := composeEntry{
+				section:   section,
+				name:      value.Content[j].Value,
+				node:      value.Content[j+1],
+				startLine: commentedStart(lines, value.Content[j].Line, root.Content[i].Line+1),
+				endLine:   sectionEnd,
+			}
+			if j == 0 {
+				entry.startLine = commentedStart(lines, root.Content[i].Line, 1)
+			}
+			if j+2 < len(value.Content) {
+				entry.endLine = commentedStart(lines, value.Content[j+2].Line, root.Content[i].Line+1) - 1
+			}
+			for entry.endLine > entry.startLine && strings.TrimSpace(lines[entry.endLine-1]) == "" {
+				entry.endLine--
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// commentedStart moves a 1-based start line up over the comment lines directly above it
+func commentedStart(lines []string, line int, floor int) int {
+	for line > floor && strings.HasPrefix(strings.TrimSpace(lines[line-2]), "#") {
+		line--
+	}
+	return line
+}
+
+// composeDockerfile resolves the Dockerfile a service builds from its build
+// key, which is either a context path or a mapping with context and dockerfile
+func composeDockerfile(dir string, build *yaml.Node) (string, int) {
+	if build == nil {
+		return "", 0
+	}
+
+	context := "."
+	dockerfile := "Dockerfile"
+	line := build.Line
+
+	switch build.Kind {
+	case yaml.ScalarNode:
+		context = build.Value
+	case yaml.MappingNode:
+		if node := yamlField(build, "context"); node != nil {
+			context = node.Value
+		}
+		if node := yamlField(build, "dockerfile"); node != nil {
+			dockerfile = node.Value
+			line = node.Line
+		}
+	default:
+		return "", 0
+	}
+
+	// Remote contexts such as git URLs aren't in the tree
+	if strings.Contains(context, "://") || strings.HasPrefix(context, "git@") {
+		return "", 0
+	}
+
+	if filepath.IsAbs(dockerfile) {
+		return filepath.Clean(dockerfile), line
+	}
+	return filepath.Join(resolveComposePath(dir, context), dockerfile), line
+}
+
+// composeNames returns the names in a short-syntax list, the keys of a
+// mapping, or the given field of each long-syntax list item
+func composeNames(node *yaml.Node, field string) []*yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	var names []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		names = append(names, node)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			names = append(names, node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				names = append(names, item)
+			} else if value := yamlField(item, field); value != nil && value.Kind == yaml.ScalarNode {
+				names = append(names, value)
+			}
+		}
+	}
+
+	return names
+}
+
+// resolveComposePath resolves a path relative to the compose file's directory
+func resolveComposePath(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// composeSymbol names a compose entry as project:section.name
+func composeSymbol(project string, section string, name string) string {
+	return project + ":" + section + "." + name
+}
+
+// yamlField returns the value of a mapping node's key, or nil
+func yamlField(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// containsString checks if a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
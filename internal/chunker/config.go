@@ -0,0 +1,284 @@
+package chunker
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// ConfigChunker implements the Chunker interface for section-based config files (INI, TOML, conf)
+type ConfigChunker struct{}
+
+// NewConfigChunker creates a new config file chunker
+func NewConfigChunker() *ConfigChunker {
+	return &ConfigChunker{}
+}
+
+// configSection is a [section] or [[array.table]] with the lines it owns
+type configSection struct {
+	path      string // empty for the lines before the first header
+	startLine int    // 1-based
+	endLine   int
+	entries   []int // 1-based start line of each entry, for splitting oversized sections
+}
+
+// configHeaderPattern matches [section], [[array.table]] and git-style [section "sub"] headers
+var (
+	configHeaderPattern     = regexp.MustCompile(`^(\[\[?)\s*([^\[\]]+?)\s*\]\]?\s*([#;].*)?$`)
+	configSubsectionPattern = regexp.MustCompile(`\s+"([^"]*)"`)
+)
+
+// Language returns the language this chunker supports
+func (c *ConfigChunker) Language() string {
+	return "config"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *ConfigChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "ini" || language == "toml" || language == "conf"
+}
+
+// Chunk splits a config file into one chunk per section, merging small sections
+// and splitting large ones between entries so that no value is ever cut in half
+func (c *ConfigChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	language := configLanguage(filePath)
+	lines := strings.Split(string(content), "\n")
+	sections := splitConfigSections(lines, language)
+
+	var chunks []model.Chunk
+	packSections(sections, options, func(group []configSection, startLine, endLine int) {
+		chunks = append(chunks, c.createChunk(filePath, lines, language, group, startLine, endLine, symbolTable))
+	})
+
+	// Keys of TOML files link to the struct fields decoding them
+	if language == "toml" {
+		addKeyReferences(tomlConfigKeys(lines), configKeySymbol, chunks, symbolTable)
+	}
+
+	return chunks, nil
+}
+
+// packSections calls emit for each run of lines that becomes a chunk. Small
+// sections are merged with their neighbours up to MaxChunkSize and oversized
+// sections are split between entries
+func packSections(sections []configSection, options ChunkingOptions, emit func(group []configSection, startLine, endLine int)) {
+	var group []configSection
+	groupLines := 0
+
+	flush := func() {
+		if len(group) > 0 {
+			emit(group, group[0].startLine, group[len(group)-1].endLine)
+			group = nil
+			groupLines = 0
+		}
+	}
+
+	for _, section := range sections {
+		size := section.endLine - section.startLine + 1
+
+		if size > options.MaxChunkSize {
+			flush()
+			splitSection(section, options, emit)
+			continue
+		}
+
+		small := size < options.MinChunkSize || groupLines < options.MinChunkSize
+		if len(group) > 0 && (!small || groupLines+size > options.MaxChunkSize) {
+			flush()
+		}
+
+		group = append(group, section)
+		groupLines += size
+	}
+	flush()
+}
+
+// splitSection splits an oversized section between entries
+func splitSection(section configSection, options ChunkingOptions, emit func(group []configSection, startLine, endLine int)) {
+	start := section.startLine
+
+	for i, entry := range section.entries {
+		next := section.endLine + 1
+		if i+1 < len(section.entries) {
+			next = section.entries[i+1]
+		}
+
+		// Cut before this entry if including it would overflow the current part
+		if entry > start && next-start > options.MaxChunkSize {
+			emit([]configSection{section}, start, entry-1)
+			start = entry
+		}
+	}
+
+	emit([]configSection{section}, start, section.endLine)
+}
+
+// createChunk creates a chunk for a range of lines owned by the given sections
+func (c *ConfigChunker) createChunk(filePath string, lines []string, language string, sections []configSection, startLine, endLine int, symbolTable *model.SymbolTable) model.Chunk {
+	content := strings.Join(lines[startLine-1:endLine], "\n")
+	chunkID := util.GenerateID(filePath, content)
+
+	var symbols []string
+	for _, section := range sections {
+		if section.path != "" {
+			symbols = append(symbols, section.path)
+		}
+	}
+
+	for _, symbol := range symbols {
+		symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+			Name:      symbol,
+			ChunkID:   chunkID,
+			FilePath:  filePath,
+			StartLine: startLine,
+			EndLine:   endLine,
+			Type:      "section",
+		})
+	}
+
+	return model.Chunk{
+		ID:         chunkID,
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   language,
+		Symbols:    symbols,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+}
+
+// splitConfigSections splits the lines into sections. Comments directly above
+// a header belong to that header's section
+func splitConfigSections(lines []string, language string) []configSection {
+	var sections []configSection
+	current := configSection{startLine: 1}
+	arrayCounts := make(map[string]int)
+
+	depth := 0
+	quote := ""
+
+	for i, line := range lines {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+		clean := depth == 0 && quote == ""
+
+		// Indented lines continue the previous value in INI-style files
+		continuation := language != "toml" && trimmed != "" && (line[0] == ' ' || line[0] == '\t')
+
+		if clean && !continuation {
+			if match := configHeaderPattern.FindStringSubmatch(trimmed); match != nil {
+				// Hand the comment block directly above the header to the new section
+				headerStart := lineNum
+				for headerStart > current.startLine && isConfigComment(lines[headerStart-2]) {
+					headerStart--
+				}
+
+				if headerStart > current.startLine {
+					current.endLine = headerStart - 1
+					current.entries = trimConfigEntries(current.entries, current.endLine)
+					sections = append(sections, current)
+				}
+
+				path := configSubsectionPattern.ReplaceAllString(match[2], ".$1")
+				if match[1] == "[[" {
+					arrayCounts[path]++
+					path = fmt.Sprintf("%s[%d]", path, arrayCounts[path])
+				}
+
+				current = configSection{path: path, startLine: headerStart}
+			} else if trimmed != "" && !isConfigComment(line) {
+				current.entries = append(current.entries, lineNum)
+			}
+		}
+
+		// Only TOML has values spanning lines, as multi-line strings, arrays and inline tables
+		if language == "toml" {
+			depth, quote = scanConfigLine(line, depth, quote)
+		}
+	}
+
+	// Drop trailing blank lines from the last section
+	end := len(lines)
+	for end > current.startLine && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	current.endLine = end
+	if current.path != "" || strings.TrimSpace(strings.Join(lines[current.startLine-1:end], "")) != "" {
+		sections = append(sections, current)
+	}
+
+	return sections
+}
+
+// scanConfigLine advances the bracket depth and multi-line string state over one line
+func scanConfigLine(line string, depth int, quote string) (int, string) {
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+
+		if quote != "" {
+			switch {
+			case ch == '\\' && quote != "'" && quote != "'''":
+				i++
+			case strings.HasPrefix(line[i:], quote):
+				i += len(quote) - 1
+				quote = ""
+			}
+			continue
+		}
+
+		switch ch {
+		case '#':
+			return depth, quote
+		case '"', '\'':
+			quote = string(ch)
+			if strings.HasPrefix(line[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+				i += 2
+			}
+		case '[', '{':
+			// A header's brackets close on the same line and never leave depth behind
+			depth++
+		case ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+
+	// Only multi-line strings can span lines
+	if len(quote) == 1 {
+		quote = ""
+	}
+
+	return depth, quote
+}
+
+// trimConfigEntries drops entry starts past the end of a section
+func trimConfigEntries(entries []int, endLine int) []int {
+	for len(entries) > 0 && entries[len(entries)-1] > endLine {
+		entries = entries[:len(entries)-1]
+	}
+	return entries
+}
+
+// isConfigComment checks if a line is a comment
+func isConfigComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";")
+}
+
+// configLanguage determines the config dialect from the file extension
+func configLanguage(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".toml":
+		return "toml"
+	case ".ini":
+		return "ini"
+	}
+	return "conf"
+}
@@ -0,0 +1,256 @@
+package chunker
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// configKey is a key found in a configuration file: a dotted path for
+// configuration keys, or the name of an environment variable
+type configKey struct {
+	path string
+	line int
+}
+
+var (
+	// yamlKeyPattern matches a mapping key, possibly starting a list item
+	yamlKeyPattern = regexp.MustCompile(`^(\s*)((?:-\s+)*)("[^"]+"|'[^']+'|[^\s#'"{\[\-][^:#]*?)\s*:(?:\s+(.*))?$`)
+
+	// tomlTablePattern and tomlKeyPattern match table headers and key/value pairs
+	tomlTablePattern = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+	tomlKeyPattern   = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+(?:\s*\.\s*[A-Za-z0-9_-]+)*|"[^"]+")\s*=`)
+
+	// dockerEnvPattern matches the variable names of an ENV instruction, in
+	// both the ENV NAME=value and ENV NAME value forms
+	dockerEnvPattern = regexp.MustCompile(`(?:^|\s)([A-Za-z_][A-Za-z0-9_]*)=`)
+)
+
+// configKeySymbol is the symbol a configuration key is defined and referenced
+// by. Keys are matched case-insensitively, like mapstructure and viper do
+func configKeySymbol(path string) string {
+	return "config:" + strings.ToLower(path)
+}
+
+// envSymbol is the symbol an environment variable is defined and referenced by
+func envSymbol(name string) string {
+	return "env:" + name
+}
+
+// addKeyReferences records a reference from the chunk holding each key to
+// the symbol the key is known by
+func addKeyReferences(keys []configKey, symbol func(string) string, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	for _, key := range keys {
+		for _, chunk := range chunks {
+			if key.line >= chunk.StartLine && key.line <= chunk.EndLine {
+				name := symbol(key.path)
+				symbolTable.AddReference(name, model.SymbolReference{
+					Name:     name,
+					ChunkID:  chunk.ID,
+					FilePath: chunk.FilePath,
+					Line:     key.line,
+				})
+				break
+			}
+		}
+	}
+}
+
+// yamlConfigKeys returns the mapping keys of a YAML file as dotted paths,
+// following indentation. List items don't add to the path
+func yamlConfigKeys(lines []string) []configKey {
+	type level struct {
+		indent int
+		key    string
+	}
+	var keys []configKey
+	var stack []level
+	blockIndent := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		// Lines of block scalars are values
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "---" {
+			stack = nil
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		match := yamlKeyPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		keyIndent := len(match[1]) + len(match[2])
+		key := strings.Trim(match[3], `"'`)
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= keyIndent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, level{indent: keyIndent, key: key})
+
+		path := make([]string, len(stack))
+		for j, l := range stack {
+			path[j] = l.key
+		}
+		keys = append(keys, configKey{path: strings.Join(path, "."), line: i + 1})
+
+		if value := strings.TrimSpace(match[4]); strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = keyIndent
+		}
+	}
+	return keys
+}
+
+// jsonConfigKeys returns the object keys of a JSON file as dotted paths.
+// Arrays don't add to the path, and keys after a syntax error are lost
+func jsonConfigKeys(content []byte) []configKey {
+	type frame struct {
+		object      bool
+		key         string
+		awaitingKey bool
+	}
+
+	// Lines are found from the decoder's offsets
+	var lineStarts []int
+	for i, b := range content {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i)
+		}
+	}
+	lineAt := func(offset int64) int {
+		return sort.SearchInts(lineStarts, int(offset)) + 1
+	}
+
+	var keys []configKey
+	var stack []*frame
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				stack = append(stack, &frame{object: true, awaitingKey: true})
+			case '[':
+				stack = append(stack, &frame{})
+			default:
+				stack = stack[:len(stack)-1]
+				if len(stack) > 0 && stack[len(stack)-1].object {
+					stack[len(stack)-1].awaitingKey = true
+				}
+			}
+			continue
+		case string:
+			if top != nil && top.object && top.awaitingKey {
+				top.key, top.awaitingKey = t, false
+				var path []string
+				for _, f := range stack {
+					if f.object {
+						path = append(path, f.key)
+					}
+				}
+				keys = append(keys, configKey{path: strings.Join(path, "."), line: lineAt(decoder.InputOffset())})
+				continue
+			}
+		}
+
+		// A value ends the current key
+		if top != nil && top.object {
+			top.awaitingKey = true
+		}
+	}
+}
+
+// tomlConfigKeys returns the keys of a TOML file as dotted paths, prefixed by
+// the table they're in
+func tomlConfigKeys(lines []string) []configKey {
+	var keys []configKey
+	table := ""
+	inString := false
+
+	for i, line := range lines {
+		// Multi-line strings can hold anything
+		if strings.Count(line, `"""`)%2 == 1 {
+			inString = !inString
+			if !inString {
+				continue
+			}
+		} else if inString {
+			continue
+		}
+
+		if match := tomlTablePattern.FindStringSubmatch(line); match != nil {
+			table = tomlKeyPath(match[1])
+			keys = append(keys, configKey{path: table, line: i + 1})
+			continue
+		}
+		if match := tomlKeyPattern.FindStringSubmatch(line); match != nil {
+			path := tomlKeyPath(match[1])
+			if table != "" {
+				path = table + "." + path
+			}
+			keys = append(keys, configKey{path: path, line: i + 1})
+		}
+	}
+	return keys
+}
+
+// tomlKeyPath normalizes a dotted TOML key, dropping quotes and spaces
+func tomlKeyPath(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// dockerfileEnvKeys returns the environment variables set by ENV instructions
+func dockerfileEnvKeys(lines []string) []configKey {
+	var keys []configKey
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "ENV") {
+			continue
+		}
+
+		// The legacy form sets a single variable, ENV NAME value
+		if !strings.Contains(fields[1], "=") {
+			keys = append(keys, configKey{path: fields[1], line: i + 1})
+			continue
+		}
+
+		// Instructions continue over lines ending with a backslash
+		for line := i; line < len(lines); line++ {
+			for _, match := range dockerEnvPattern.FindAllStringSubmatch(lines[line], -1) {
+				keys = append(keys, configKey{path: match[1], line: line + 1})
+			}
+			if !strings.HasSuffix(strings.TrimSpace(lines[line]), "\\") {
+				i = line
+				break
+			}
+		}
+	}
+	return keys
+}
@@ -75,6 +75,9 @@ func (c *DockerfileChunker) Chunk(filePath string, content []byte, symbolTable *
 		chunks = append(chunks, chunk)
 	}
 	
+	// Variables set by ENV link to the struct fields reading them
+	addKeyReferences(dockerfileEnvKeys(strings.Split(string(content), "\n")), envSymbol, chunks, symbolTable)
+	
 	return chunks, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/stream-ai/chunk/internal/model"
 	"github.com/stream-ai/chunk/pkg/util"
@@ -81,6 +82,14 @@ func (c *GenericChunker) Chunk(filePath string, content []byte, symbolTable *mod
 		chunks = append(chunks, chunk)
 	}
 
+	// Keys of YAML and JSON files link to the struct fields decoding them
+	switch language {
+	case "yaml", "yml":
+		addKeyReferences(yamlConfigKeys(strings.Split(string(content), "\n")), configKeySymbol, chunks, symbolTable)
+	case "json":
+		addKeyReferences(jsonConfigKeys(content), configKeySymbol, chunks, symbolTable)
+	}
+
 	return chunks, nil
 }
 
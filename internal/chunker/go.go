@@ -2,9 +2,14 @@
 package chunker
 
 import (
+	"bytes"
+	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/build/constraint"
 	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/stream-ai/chunk/internal/model"
@@ -12,11 +17,39 @@ import (
 )
 
 // GoChunker implements the Chunker interface for Go code using Go's AST parser
-type GoChunker struct{}
+type GoChunker struct {
+	// TypeCheck resolves references by type-checking each package with go/types
+	// instead of matching identifier names against every definition
+	TypeCheck bool
+
+	// Generated is the policy for files with a "Code generated ... DO NOT
+	// EDIT." header: GeneratedKeep, GeneratedSkip or GeneratedSummarize
+	Generated string
+
+	// Type-checking state, shared by the files of a package
+	typeFset *token.FileSet
+	importer *goImporter
+	packages map[string]*goPackage
+
+	// Modules by directory, for resolving import paths, and the root of the
+	// tree being chunked, which the search for go.mod and go.work files
+	// doesn't go above
+	modules map[string]*goModule
+	rootDir string
+
+	// Packages whose API summary was created, by directory and name
+	summarized map[string]bool
+
+	// Names of the cobra commands created by functions and variables, by directory
+	commands map[string]map[string]string
+
+	// Values of package-level constants and variables, by directory and name
+	values map[string]map[string]goDecl
+}
 
 // NewGoChunker creates a new Go code chunker
 func NewGoChunker() *GoChunker {
-	return &GoChunker{}
+	return &GoChunker{Generated: GeneratedKeep}
 }
 
 // Language returns the language this chunker supports
@@ -31,12 +64,16 @@ func (c *GoChunker) CanHandle(filePath string, language string, framework string
 
 // Chunk splits the Go file content into chunks using the Go AST parser
 func (c *GoChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
-	// Setup file set and parse the file
+	if options.RootDir != c.rootDir {
+		c.rootDir, c.modules = options.RootDir, nil
+	}
+
+	// Setup file set and parse the file, leaving out declarations with syntax errors
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
-	if err != nil {
-		// If parsing fails, fall back to line-based chunking
-		return c.fallbackChunking(filePath, content, symbolTable, options)
+	file, broken := parseGoFile(fset, filePath, content)
+	if file == nil {
+		// If the file can't be parsed at all, fall back to line-based chunking
+		return c.brokenChunks(filePath, content, broken, symbolTable, options), nil
 	}
 
 	// Extract package name
@@ -47,25 +84,67 @@ func (c *GoChunker) Chunk(filePath string, content []byte, symbolTable *model.Sy
 	// Process imports
 	imports := c.extractImports(file)
 
+	generated := ast.IsGenerated(file)
+	if generated {
+		switch c.Generated {
+		case GeneratedSkip:
+			return nil, nil
+		case GeneratedSummarize:
+			chunks = []model.Chunk{c.summarizeGenerated(fset, file, filePath, content, imports, symbolTable)}
+			c.addMemberReferences(filePath, file, chunks, symbolTable)
+			if summary, ok := c.packageSummary(filePath, content, file, symbolTable, options); ok {
+				chunks = append(chunks, summary)
+			}
+			c.recordPackage(filePath, imports, chunks, symbolTable)
+			return chunks, nil
+		}
+	}
+
+	var typed *goPackage
+	if c.TypeCheck {
+		typed = c.typeCheckedPackage(filePath, content)
+	}
+	if typed != nil {
+		typed.nameImports(filePath, file)
+	}
+
 	// First pass: Create chunks for top-level declarations
 	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
 			// Function or method declaration
-			chunk := c.processFuncDecl(fset, d, filePath, content, packageName, imports, symbolTable)
-			chunks = append(chunks, chunk)
+			funcChunks := c.processFuncDecl(fset, file, d, filePath, content, packageName, symbolTable, options)
+
+			// Type checking already resolves what tests call
+			if kind, name := goTestFunc(filePath, d); kind != "" {
+				c.addTestReferences(fset, file, d, kind, name, funcChunks, typed == nil, symbolTable)
+			}
+			chunks = append(chunks, funcChunks...)
+
+			c.addConstraintRelations(fset, file, d.Type.TypeParams, funcChunks[0].Symbols[0], funcChunks[0], symbolTable)
+
+			// Type checking resolves receivers along with every other identifier
+			if typed == nil {
+				c.addReceiverReference(d, funcChunks[0], symbolTable)
+			}
 
 		case *ast.GenDecl:
 			// Type, const, var declarations
 			if d.Tok == token.TYPE || d.Tok == token.CONST || d.Tok == token.VAR {
-				chunk := c.processGenDecl(fset, d, filePath, content, packageName, imports, symbolTable)
+				chunk := c.processGenDecl(fset, file, d, filePath, content, packageName, typed == nil, symbolTable)
 				if chunk.Content != "" {
+					if d.Tok == token.VAR && strings.HasSuffix(filePath, "_test.go") {
+						c.addTestTableReferences(fset, d, &chunk, symbolTable)
+					}
 					chunks = append(chunks, chunk)
 				}
 			}
 		}
 	}
 
+	// Declarations that didn't parse are chunked by lines
+	chunks = append(chunks, c.brokenChunks(filePath, content, broken, symbolTable, options)...)
+
 	// Handle imports section if not already included in a chunk
 	importsChunk := c.processImportsSection(fset, file, filePath, content, packageName, symbolTable)
 	if importsChunk.Content != "" {
@@ -78,17 +157,55 @@ func (c *GoChunker) Chunk(filePath string, content []byte, symbolTable *model.Sy
 		chunks = append(chunks, packageChunk)
 	}
 
+	// Commands are documented in chunks of their own
+	chunks = append(chunks, c.collectCommands(fset, file, filePath, symbolTable)...)
+
+	// File-level build constraints apply to every chunk of the file
+	if expr := buildConstraint(file); expr != "" {
+		for i := range chunks {
+			setMetadata(&chunks[i], "build", expr)
+		}
+	}
+
+	if generated {
+		for i := range chunks {
+			setMetadata(&chunks[i], "generated", "true")
+		}
+	}
+
+	// Every chunk of the package links to the package's API summary
+	c.addMemberReferences(filePath, file, chunks, symbolTable)
+	if summary, ok := c.packageSummary(filePath, content, file, symbolTable, options); ok {
+		chunks = append(chunks, summary)
+	}
+
+	c.recordPackage(filePath, imports, chunks, symbolTable)
+	c.collectMessages(fset, file, filePath, typed, chunks)
+
 	// Second pass: Collect references
-	c.collectReferences(fset, file, chunks, symbolTable)
+	if typed != nil {
+		c.collectTypedReferences(typed, filePath, chunks, symbolTable)
+		c.collectTypedImplements(typed, filePath, chunks, symbolTable)
+	} else {
+		c.collectReferences(fset, file, filePath, chunks, symbolTable)
+	}
+	c.collectCalls(fset, file, filePath, typed, chunks, symbolTable)
 
 	return chunks, nil
 }
 
-// processFuncDecl creates a chunk for a function declaration
-func (c *GoChunker) processFuncDecl(fset *token.FileSet, decl *ast.FuncDecl, filePath string, content []byte, packageName string, imports []string, symbolTable *model.SymbolTable) model.Chunk {
-	// Get position information
-	startPos := fset.Position(decl.Pos())
-	endPos := fset.Position(decl.End())
+// processFuncDecl creates a chunk for a function declaration, or one chunk per
+// part if the function is longer than MaxChunkSize
+func (c *GoChunker) processFuncDecl(fset *token.FileSet, file *ast.File, decl *ast.FuncDecl, filePath string, content []byte, packageName string, symbolTable *model.SymbolTable, options ChunkingOptions) []model.Chunk {
+	// Get position information, including the doc comment and directives
+	startPos, endPos := declPositions(fset, file, decl, decl.Doc, content)
+
+	// Determine symbol name
+	symbolName := funcSymbol(decl)
+
+	if decl.Body != nil && options.MaxChunkSize > 0 && endPos.Line-startPos.Line+1 > options.MaxChunkSize {
+		return c.splitFuncDecl(fset, file, decl, startPos.Line, endPos.Line, filePath, content, symbolName, symbolTable, options)
+	}
 
 	// Extract function content
 	funcContent := string(content[startPos.Offset:endPos.Offset])
@@ -96,33 +213,6 @@ func (c *GoChunker) processFuncDecl(fset *token.FileSet, decl *ast.FuncDecl, fil
 	// Generate ID for the chunk
 	chunkID := util.GenerateID(filePath, funcContent)
 
-	// Determine symbol name
-	var symbolName string
-	if decl.Recv == nil {
-		// Regular function
-		symbolName = decl.Name.Name
-	} else {
-		// Method
-		// Get the receiver type
-		receiver := decl.Recv.List[0].Type
-		var receiverType string
-
-		// Handle pointer receivers like (*T)
-		if starExpr, ok := receiver.(*ast.StarExpr); ok {
-			if ident, ok := starExpr.X.(*ast.Ident); ok {
-				receiverType = ident.Name
-			}
-		} else if ident, ok := receiver.(*ast.Ident); ok {
-			receiverType = ident.Name
-		}
-
-		if receiverType != "" {
-			symbolName = receiverType + "." + decl.Name.Name
-		} else {
-			symbolName = decl.Name.Name
-		}
-	}
-
 	// Create chunk
 	chunk := model.Chunk{
 		ID:         chunkID,
@@ -132,7 +222,7 @@ func (c *GoChunker) processFuncDecl(fset *token.FileSet, decl *ast.FuncDecl, fil
 		Content:    funcContent,
 		Language:   "go",
 		Symbols:    []string{symbolName},
-		Imports:    imports,
+		Imports:    usedImports(file, decl, nil),
 		TokenCount: util.EstimateTokenCount(funcContent),
 	}
 
@@ -146,39 +236,295 @@ func (c *GoChunker) processFuncDecl(fset *token.FileSet, decl *ast.FuncDecl, fil
 		Type:      "function",
 	})
 
-	// For methods, also record the receiver type to establish relationships
-	if decl.Recv != nil {
-		receiver := decl.Recv.List[0].Type
-		var receiverType string
+	return []model.Chunk{chunk}
+}
+
+// goBoundary is a line where a part of a split function may start, with the
+// opening lines of the statements enclosing it
+type goBoundary struct {
+	line    int
+	context []int
+}
+
+// splitFuncDecl splits a long function between statements. The first part keeps
+// the function's symbol, and every later part repeats the signature and the
+// opening lines of its enclosing statements as a header and is named
+// Func#partN with a link to the first part
+func (c *GoChunker) splitFuncDecl(fset *token.FileSet, file *ast.File, decl *ast.FuncDecl, startLine, endLine int, filePath string, content []byte, symbolName string, symbolTable *model.SymbolTable, options ChunkingOptions) []model.Chunk {
+	lines := strings.Split(string(content), "\n")
+	signatureEnd := fset.Position(decl.Body.Lbrace).Line
+	signature := lines[fset.Position(decl.Pos()).Line-1 : signatureEnd]
 
-		// Handle pointer receivers like (*T)
-		if starExpr, ok := receiver.(*ast.StarExpr); ok {
-			if ident, ok := starExpr.X.(*ast.Ident); ok {
-				receiverType = ident.Name
+	var chunks []model.Chunk
+	emit := func(partStart, partEnd int, context []int) {
+		for partEnd > partStart && strings.TrimSpace(lines[partEnd-1]) == "" {
+			partEnd--
+		}
+		partContent := strings.Join(lines[partStart-1:partEnd], "\n")
+		partSymbol := symbolName
+		parentID := ""
+		if len(chunks) > 0 {
+			header := append([]string{}, signature...)
+			for _, line := range context {
+				header = append(header, lines[line-1])
 			}
-		} else if ident, ok := receiver.(*ast.Ident); ok {
-			receiverType = ident.Name
+			partContent = strings.Join(header, "\n") + "\n" + partContent
+			partSymbol = fmt.Sprintf("%s#part%d", symbolName, len(chunks)+1)
+			parentID = chunks[0].ID
+		}
+
+		// A part uses the imports selected in its lines and its header
+		partImports := usedImports(file, decl, func(node ast.Node) bool {
+			line := fset.Position(node.Pos()).Line
+			return line <= signatureEnd || (line >= partStart && line <= partEnd) || slices.Contains(context, line)
+		})
+
+		chunkID := util.GenerateID(filePath, partContent)
+		chunks = append(chunks, model.Chunk{
+			ID:         chunkID,
+			FilePath:   filePath,
+			StartLine:  partStart,
+			EndLine:    partEnd,
+			Content:    partContent,
+			Language:   "go",
+			Symbols:    []string{partSymbol},
+			Imports:    partImports,
+			TokenCount: util.EstimateTokenCount(partContent),
+			ParentID:   parentID,
+		})
+
+		symbolTable.AddDefinition(partSymbol, model.SymbolDefinition{
+			Name:      partSymbol,
+			ChunkID:   chunkID,
+			FilePath:  filePath,
+			StartLine: partStart,
+			EndLine:   partEnd,
+			Type:      "function",
+		})
+	}
+
+	// Later parts carry a header, so statements are opened up against a smaller budget
+	budget := options.MaxChunkSize - len(signature)
+	boundaries := goStatementBoundaries(fset, lines, decl.Body.List, nil, budget)
+
+	// Cut before a boundary if the part including the next statement, with its header, would overflow
+	part := goBoundary{line: startLine}
+	for i, boundary := range boundaries {
+		next := endLine + 1
+		if i+1 < len(boundaries) {
+			next = boundaries[i+1].line
 		}
 
-		if receiverType != "" {
-			// Add a reference to the type
-			symbolTable.AddReference(receiverType, model.SymbolReference{
-				Name:     receiverType,
-				ChunkID:  chunkID,
-				FilePath: filePath,
-				Line:     startPos.Line,
-			})
+		headerLines := 0
+		if part.line > startLine {
+			headerLines = len(signature) + len(part.context)
+		}
+		if boundary.line > part.line && next-part.line+headerLines > options.MaxChunkSize {
+			emit(part.line, boundary.line-1, part.context)
+			part = boundary
 		}
 	}
+	emit(part.line, endLine, part.context)
 
-	return chunk
+	return chunks
 }
 
-// processGenDecl creates a chunk for a type, const, or var declaration
-func (c *GoChunker) processGenDecl(fset *token.FileSet, decl *ast.GenDecl, filePath string, content []byte, packageName string, imports []string, symbolTable *model.SymbolTable) model.Chunk {
-	// Get position information
-	startPos := fset.Position(decl.Pos())
-	endPos := fset.Position(decl.End())
+// goStatementBoundaries returns the lines where a part may start: before each
+// statement and the comments above it. Statements longer than the budget are
+// opened up, so that their blocks and case clauses can be split too
+func goStatementBoundaries(fset *token.FileSet, lines []string, stmts []ast.Stmt, context []int, budget int) []goBoundary {
+	var boundaries []goBoundary
+
+	for _, stmt := range stmts {
+		stmtLine := fset.Position(stmt.Pos()).Line
+		line := stmtLine
+		for line > 1 && strings.HasPrefix(strings.TrimSpace(lines[line-2]), "//") {
+			line--
+		}
+		boundaries = append(boundaries, goBoundary{line: line, context: context})
+
+		if fset.Position(stmt.End()).Line-stmtLine+1 <= budget-len(context) {
+			continue
+		}
+		inner := append(append([]int{}, context...), stmtLine)
+		for _, block := range goNestedStatements(stmt) {
+			boundaries = append(boundaries, goStatementBoundaries(fset, lines, block, inner, budget)...)
+		}
+	}
+
+	return boundaries
+}
+
+// goNestedStatements returns the statement lists directly inside a compound statement
+func goNestedStatements(stmt ast.Stmt) [][]ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		return [][]ast.Stmt{s.List}
+	case *ast.LabeledStmt:
+		return [][]ast.Stmt{{s.Stmt}}
+	case *ast.IfStmt:
+		blocks := [][]ast.Stmt{s.Body.List}
+		if s.Else != nil {
+			blocks = append(blocks, []ast.Stmt{s.Else})
+		}
+		return blocks
+	case *ast.ForStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.RangeStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.SwitchStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.TypeSwitchStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.SelectStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.CaseClause:
+		return [][]ast.Stmt{s.Body}
+	case *ast.CommClause:
+		return [][]ast.Stmt{s.Body}
+	}
+	return nil
+}
+
+// declPositions returns the start and end of a declaration extended over its
+// doc comment, the directive-only comment groups (such as //go:generate) separated
+// from it by blank lines, and a comment trailing its last line (such as //nolint)
+func declPositions(fset *token.FileSet, file *ast.File, decl ast.Decl, doc *ast.CommentGroup, content []byte) (token.Position, token.Position) {
+	tokFile := fset.File(decl.Pos())
+	start := tokFile.Offset(decl.Pos())
+	end := tokFile.Offset(decl.End())
+	if doc != nil {
+		start = tokFile.Offset(doc.Pos())
+	}
+
+	// Directive groups above may not reach back into the previous declaration's last line
+	floor := tokFile.Offset(file.Name.End())
+	for _, other := range file.Decls {
+		if other.End() <= decl.Pos() {
+			floor = tokFile.Offset(other.End())
+		}
+	}
+	if lineEnd := bytes.IndexByte(content[floor:], '\n'); lineEnd >= 0 {
+		floor += lineEnd
+	}
+	for i := len(file.Comments) - 1; i >= 0; i-- {
+		group := file.Comments[i]
+		groupStart := tokFile.Offset(group.Pos())
+		groupEnd := tokFile.Offset(group.End())
+		if groupEnd > start || groupStart < floor {
+			continue
+		}
+		if strings.TrimSpace(string(content[groupEnd:start])) != "" || !isDirectiveGroup(group) {
+			break
+		}
+		start = groupStart
+	}
+
+	// Include a trailing comment on the last line
+	lineEnd := bytes.IndexByte(content[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(content) - end
+	}
+	if rest := string(content[end : end+lineEnd]); strings.HasPrefix(strings.TrimSpace(rest), "//") {
+		end += len(strings.TrimRight(rest, " \t\r"))
+	}
+
+	return tokFile.Position(tokFile.Pos(start)), tokFile.Position(tokFile.Pos(end))
+}
+
+// isDirectiveGroup checks if a comment group holds nothing but //go: directives and //nolint markers
+func isDirectiveGroup(group *ast.CommentGroup) bool {
+	for _, comment := range group.List {
+		if !strings.HasPrefix(comment.Text, "//go:") && !strings.HasPrefix(comment.Text, "//nolint") {
+			return false
+		}
+	}
+	return true
+}
+
+// buildConstraint returns the file's //go:build expression, or one derived from
+// legacy // +build lines, or an empty string
+func buildConstraint(file *ast.File) string {
+	var plusBuild []constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if constraint.IsGoBuild(comment.Text) {
+				if expr, err := constraint.Parse(comment.Text); err == nil {
+					return expr.String()
+				}
+			}
+			if constraint.IsPlusBuild(comment.Text) {
+				if expr, err := constraint.Parse(comment.Text); err == nil {
+					plusBuild = append(plusBuild, expr)
+				}
+			}
+		}
+	}
+
+	// Multiple +build lines are ANDed together
+	if len(plusBuild) == 0 {
+		return ""
+	}
+	expr := plusBuild[0]
+	for _, other := range plusBuild[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: other}
+	}
+	return expr.String()
+}
+
+// funcSymbol returns the symbol of a function, or Type.Method for a method
+func funcSymbol(decl *ast.FuncDecl) string {
+	if receiverType := receiverTypeName(decl); receiverType != "" {
+		return receiverType + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+// receiverTypeName returns the name of a method's receiver type, or an empty string
+func receiverTypeName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	receiver := decl.Recv.List[0].Type
+
+	// Handle pointer receivers like (*T)
+	if starExpr, ok := receiver.(*ast.StarExpr); ok {
+		receiver = starExpr.X
+	}
+	// Handle generic receivers like T[K]
+	switch index := receiver.(type) {
+	case *ast.IndexExpr:
+		receiver = index.X
+	case *ast.IndexListExpr:
+		receiver = index.X
+	}
+	if ident, ok := receiver.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// addReceiverReference records a method's reference to its receiver type to establish relationships
+func (c *GoChunker) addReceiverReference(decl *ast.FuncDecl, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	if receiverType := receiverTypeName(decl); receiverType != "" {
+		symbolTable.AddReference(receiverType, model.SymbolReference{
+			Name:     receiverType,
+			ChunkID:  chunk.ID,
+			FilePath: chunk.FilePath,
+			Line:     chunk.StartLine,
+		})
+	}
+}
+
+// processGenDecl creates a chunk for a type, const, or var declaration.
+// Interfaces list their methods for name-based interface satisfaction unless
+// the package is type-checked
+func (c *GoChunker) processGenDecl(fset *token.FileSet, file *ast.File, decl *ast.GenDecl, filePath string, content []byte, packageName string, matchMethods bool, symbolTable *model.SymbolTable) model.Chunk {
+	// Get position information, including the doc comment and directives
+	startPos, endPos := declPositions(fset, file, decl, decl.Doc, content)
 
 	// Extract declaration content
 	declContent := string(content[startPos.Offset:endPos.Offset])
@@ -189,6 +535,7 @@ func (c *GoChunker) processGenDecl(fset *token.FileSet, decl *ast.GenDecl, fileP
 	// Extract symbols based on declaration type
 	var symbols []string
 	var declType string
+	interfaces := make(map[string][]string)
 
 	switch decl.Tok {
 	case token.TYPE:
@@ -196,6 +543,9 @@ func (c *GoChunker) processGenDecl(fset *token.FileSet, decl *ast.GenDecl, fileP
 		for _, spec := range decl.Specs {
 			if typeSpec, ok := spec.(*ast.TypeSpec); ok {
 				symbols = append(symbols, typeSpec.Name.Name)
+				if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+					interfaces[typeSpec.Name.Name] = interfaceMethodNames(iface)
+				}
 			}
 		}
 	case token.CONST:
@@ -232,20 +582,35 @@ func (c *GoChunker) processGenDecl(fset *token.FileSet, decl *ast.GenDecl, fileP
 		Content:    declContent,
 		Language:   "go",
 		Symbols:    symbols,
-		Imports:    imports,
+		Imports:    usedImports(file, decl, nil),
 		TokenCount: util.EstimateTokenCount(declContent),
 	}
 
 	// Add symbol definitions to symbol table
 	for _, symbol := range symbols {
-		symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+		def := model.SymbolDefinition{
 			Name:      symbol,
 			ChunkID:   chunkID,
 			FilePath:  filePath,
 			StartLine: startPos.Line,
 			EndLine:   endPos.Line,
 			Type:      declType,
-		})
+		}
+		if methods, ok := interfaces[symbol]; ok {
+			def.Type = "interface"
+			if matchMethods {
+				def.Methods = methods
+			}
+		}
+		symbolTable.AddDefinition(symbol, def)
+	}
+
+	switch decl.Tok {
+	case token.TYPE:
+		c.addTypeRelations(fset, file, decl, chunk, symbolTable)
+		c.addStructSchema(file, decl, &chunk, symbolTable)
+	case token.VAR:
+		c.addEmbedReferences(fset, decl, chunk, symbolTable)
 	}
 
 	return chunk
@@ -338,14 +703,54 @@ func (c *GoChunker) extractImports(file *ast.File) []string {
 	return imports
 }
 
+// usedImports returns the imports of a file that a node selects from, in the
+// file's order. If keep is set, only the selectors it accepts count. Blank
+// imports are never used, and dot imports always are since their names can't
+// be told apart from the package's own
+func usedImports(file *ast.File, node ast.Node, keep func(ast.Node) bool) []string {
+	used := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		selector, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// Package names are left unresolved, unlike local variables shadowing them
+		if x, ok := selector.X.(*ast.Ident); ok && x.Obj == nil && (keep == nil || keep(selector)) {
+			if path := importPathOf(file, x.Name); path != "" {
+				used[path] = true
+			}
+		}
+		return true
+	})
+
+	var imports []string
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || slices.Contains(imports, path) {
+			continue
+		}
+		if imp.Name != nil && imp.Name.Name == "_" {
+			continue
+		}
+		if used[path] || (imp.Name != nil && imp.Name.Name == ".") {
+			imports = append(imports, path)
+		}
+	}
+	return imports
+}
+
 // collectReferences processes the AST to find references to symbols
-func (c *GoChunker) collectReferences(fset *token.FileSet, file *ast.File, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+func (c *GoChunker) collectReferences(fset *token.FileSet, file *ast.File, filePath string, chunks []model.Chunk, symbolTable *model.SymbolTable) {
 	// Visitor to find identifier references
 	visitor := &referenceVisitor{
 		fset:        fset,
+		file:        file,
 		symbolTable: symbolTable,
 		chunks:      chunks,
-		filePath:    file.Name.Name,
+		filePath:    filePath,
+		qualify: func(importPath string) string {
+			return c.canonicalImport(filepath.Dir(filePath), importPath)
+		},
 	}
 
 	// Walk the AST to find references
@@ -355,10 +760,14 @@ func (c *GoChunker) collectReferences(fset *token.FileSet, file *ast.File, chunk
 // referenceVisitor implements the ast.Visitor interface to find references
 type referenceVisitor struct {
 	fset         *token.FileSet
+	file         *ast.File
 	symbolTable  *model.SymbolTable
 	chunks       []model.Chunk
 	filePath     string
 	currentChunk string
+
+	// qualify maps an import path to the path its package declares
+	qualify func(importPath string) string
 }
 
 // Visit implements the ast.Visitor interface
@@ -376,6 +785,24 @@ func (v *referenceVisitor) Visit(node ast.Node) ast.Visitor {
 		}
 	}
 
+	// Selectors into imported packages only refer to that package
+	if selector, ok := node.(*ast.SelectorExpr); ok {
+		if x, ok := selector.X.(*ast.Ident); ok && x.Obj == nil {
+			if path := importPathOf(v.file, x.Name); path != "" {
+				if v.currentChunk != "" {
+					v.symbolTable.AddReference(selector.Sel.Name, model.SymbolReference{
+						Name:     selector.Sel.Name,
+						ChunkID:  v.currentChunk,
+						FilePath: v.filePath,
+						Line:     v.fset.Position(selector.Sel.Pos()).Line,
+						Package:  v.qualify(path),
+					})
+				}
+				return nil
+			}
+		}
+	}
+
 	// Check for identifier references
 	if ident, ok := node.(*ast.Ident); ok {
 		name := ident.Name
@@ -461,40 +888,6 @@ func isKeyword(name string) bool {
 	return keywords[name]
 }
 
-// fallbackChunking provides a line-based chunking as a fallback
-func (c *GoChunker) fallbackChunking(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
-	lines := strings.Split(string(content), "\n")
-	var chunks []model.Chunk
-
-	// Simple line-based chunking
-	for i := 0; i < len(lines); i += options.MaxChunkSize {
-		end := i + options.MaxChunkSize
-		if end > len(lines) {
-			end = len(lines)
-		}
-
-		chunkLines := lines[i:end]
-		chunkContent := strings.Join(chunkLines, "\n")
-
-		// Generate ID
-		chunkID := util.GenerateID(filePath, chunkContent)
-
-		chunk := model.Chunk{
-			ID:         chunkID,
-			FilePath:   filePath,
-			StartLine:  i + 1,
-			EndLine:    end,
-			Content:    chunkContent,
-			Language:   "go",
-			TokenCount: util.EstimateTokenCount(chunkContent),
-		}
-
-		chunks = append(chunks, chunk)
-	}
-
-	return chunks, nil
-}
-
 // FindRelatedChunks finds chunks related to the given chunk
 func (c *GoChunker) FindRelatedChunks(chunk model.Chunk, symbolTable *model.SymbolTable) []string {
 	relatedChunks := make(map[string]bool)
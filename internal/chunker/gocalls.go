@@ -0,0 +1,325 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// goCallee is the function or method a call resolves to. Callees found by
+// type checking are located by their declaration, others by package
+type goCallee struct {
+	symbol  string
+	pkg     string
+	defFile string
+	defLine int
+}
+
+// goCallScope resolves the callees of the calls within one top-level declaration
+type goCallScope struct {
+	c       *GoChunker
+	fset    *token.FileSet
+	file    *ast.File
+	decl    ast.Decl
+	dir     string
+	pkgPath string
+	typed   *goPackage
+
+	// varTypes maps local variables to their type, as a callee with no method
+	varTypes map[string]goCallee
+
+	// funcValues maps local variables to the function assigned to them
+	funcValues map[string]goCallee
+}
+
+// collectCalls records a "call" reference for each call in a file's
+// declarations whose callee can be resolved: direct calls, method calls on
+// receivers, parameters and variables of known types, and calls through
+// variables holding a function. With a type-checked package, callees are
+// resolved by go/types instead of by name
+func (c *GoChunker) collectCalls(fset *token.FileSet, file *ast.File, filePath string, typed *goPackage, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	if typed != nil {
+		fset, file = c.typeFset, typed.files[filePath]
+	}
+
+	chunkAt := func(line int) string {
+		for _, chunk := range chunks {
+			if line >= chunk.StartLine && line <= chunk.EndLine {
+				return chunk.ID
+			}
+		}
+		return ""
+	}
+
+	dir := filepath.Dir(filePath)
+	for _, decl := range file.Decls {
+		scope := &goCallScope{
+			c:          c,
+			fset:       fset,
+			file:       file,
+			decl:       decl,
+			dir:        dir,
+			pkgPath:    c.packagePath(dir),
+			typed:      typed,
+			varTypes:   make(map[string]goCallee),
+			funcValues: make(map[string]goCallee),
+		}
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+			scope.declareFields(funcDecl.Recv)
+		}
+
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncType:
+				scope.declareFields(n.Params)
+				scope.declareFields(n.Results)
+			case *ast.ValueSpec:
+				for i, name := range n.Names {
+					var value ast.Expr
+					if i < len(n.Values) {
+						value = n.Values[i]
+					}
+					scope.declare(name.Name, n.Type, value)
+				}
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						if ident, ok := lhs.(*ast.Ident); ok {
+							scope.declare(ident.Name, nil, n.Rhs[i])
+						}
+					}
+				}
+			case *ast.CallExpr:
+				callee := scope.resolve(n.Fun)
+				if callee == nil {
+					return true
+				}
+				line := fset.Position(n.Pos()).Line
+				if chunkID := chunkAt(line); chunkID != "" {
+					symbolTable.AddReference(callee.symbol, model.SymbolReference{
+						Name:           callee.symbol,
+						ChunkID:        chunkID,
+						FilePath:       filePath,
+						Line:           line,
+						Kind:           "call",
+						Package:        callee.pkg,
+						DefinitionFile: callee.defFile,
+						DefinitionLine: callee.defLine,
+					})
+				}
+			}
+			return true
+		})
+	}
+}
+
+// declareFields records the types of parameters, results and receivers
+func (s *goCallScope) declareFields(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			s.declare(name.Name, field.Type, nil)
+		}
+	}
+}
+
+// declare records what is known about a local variable from its declared
+// type or the value assigned to it
+func (s *goCallScope) declare(name string, typeExpr ast.Expr, value ast.Expr) {
+	if name == "_" {
+		return
+	}
+	if typeExpr != nil {
+		if typ := s.typeOf(typeExpr); typ != nil {
+			s.varTypes[name] = *typ
+		}
+		return
+	}
+	if value == nil {
+		return
+	}
+
+	// Values that are functions make the variable callable
+	if _, isCall := value.(*ast.CallExpr); !isCall {
+		if callee := s.resolve(value); callee != nil {
+			s.funcValues[name] = *callee
+			return
+		}
+	}
+
+	if typ := s.valueType(value); typ != nil {
+		s.varTypes[name] = *typ
+	}
+}
+
+// typeOf returns the named type of a type expression, looking through pointers
+func (s *goCallScope) typeOf(expr ast.Expr) *goCallee {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return s.typeOf(e.X)
+	case *ast.IndexExpr:
+		return s.typeOf(e.X)
+	case *ast.IndexListExpr:
+		return s.typeOf(e.X)
+	case *ast.Ident:
+		if isBasicType(e.Name) {
+			return nil
+		}
+		return &goCallee{symbol: e.Name, pkg: s.pkgPath}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			if path := importPathOf(s.file, x.Name); path != "" {
+				return &goCallee{symbol: e.Sel.Name, pkg: s.c.canonicalImport(s.dir, path)}
+			}
+		}
+	}
+	return nil
+}
+
+// valueType guesses the type of a value: composite literals, new(T), and
+// constructors following the NewT convention
+func (s *goCallScope) valueType(value ast.Expr) *goCallee {
+	switch v := value.(type) {
+	case *ast.CompositeLit:
+		if v.Type != nil {
+			return s.typeOf(v.Type)
+		}
+	case *ast.UnaryExpr:
+		if v.Op == token.AND {
+			return s.valueType(v.X)
+		}
+	case *ast.ParenExpr:
+		return s.valueType(v.X)
+	case *ast.CallExpr:
+		switch fun := v.Fun.(type) {
+		case *ast.Ident:
+			if fun.Name == "new" && len(v.Args) == 1 {
+				return s.typeOf(v.Args[0])
+			}
+			if typeName, ok := strings.CutPrefix(fun.Name, "New"); ok && ast.IsExported(typeName) {
+				return &goCallee{symbol: typeName, pkg: s.pkgPath}
+			}
+		case *ast.SelectorExpr:
+			if typeName, ok := strings.CutPrefix(fun.Sel.Name, "New"); ok && ast.IsExported(typeName) {
+				if typ := s.typeOf(fun); typ != nil {
+					return &goCallee{symbol: typeName, pkg: typ.pkg}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the function or method an expression refers to, or nil
+func (s *goCallScope) resolve(expr ast.Expr) *goCallee {
+	if s.typed != nil {
+		if callee, ok := s.resolveTyped(expr); ok {
+			return callee
+		}
+	}
+
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return s.resolve(e.X)
+	case *ast.IndexExpr:
+		return s.resolve(e.X)
+	case *ast.IndexListExpr:
+		return s.resolve(e.X)
+
+	case *ast.Ident:
+		if callee, ok := s.funcValues[e.Name]; ok {
+			return &callee
+		}
+		if _, ok := s.varTypes[e.Name]; ok || isLocalIdent(s.decl, e) || isKeyword(e.Name) {
+			return nil
+		}
+		// Builtins and conversions to basic types, unless declared in this file
+		if e.Obj == nil && types.Universe.Lookup(e.Name) != nil {
+			return nil
+		}
+		return &goCallee{symbol: e.Name, pkg: s.pkgPath}
+
+	case *ast.SelectorExpr:
+		var typ *goCallee
+		switch x := e.X.(type) {
+		case *ast.Ident:
+			if t, ok := s.varTypes[x.Name]; ok {
+				typ = &t
+			} else if x.Obj != nil && x.Obj.Kind == ast.Typ {
+				// Method expressions such as T.Method
+				typ = &goCallee{symbol: x.Name, pkg: s.pkgPath}
+			} else if x.Obj == nil {
+				if path := importPathOf(s.file, x.Name); path != "" {
+					return &goCallee{symbol: e.Sel.Name, pkg: s.c.canonicalImport(s.dir, path)}
+				}
+			}
+		default:
+			typ = s.valueType(x)
+		}
+		if typ != nil {
+			return &goCallee{symbol: typ.symbol + "." + e.Sel.Name, pkg: typ.pkg}
+		}
+	}
+	return nil
+}
+
+// resolveTyped resolves an expression with go/types. It reports false if
+// type checking knows nothing about it, e.g. a selector into a package from
+// outside the standard library
+func (s *goCallScope) resolveTyped(expr ast.Expr) (*goCallee, bool) {
+	var obj types.Object
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.IndexExpr:
+		return s.resolveTyped(e.X)
+	case *ast.IndexListExpr:
+		return s.resolveTyped(e.X)
+	case *ast.Ident:
+		obj = s.typed.info.Uses[e]
+		if _, ok := obj.(*types.Var); ok {
+			// Variables holding functions are tracked by assignment
+			callee, ok := s.funcValues[e.Name]
+			if !ok {
+				return nil, true
+			}
+			return &callee, true
+		}
+	case *ast.SelectorExpr:
+		if selection, ok := s.typed.info.Selections[e]; ok {
+			obj = selection.Obj()
+		} else {
+			obj = s.typed.info.Uses[e.Sel]
+		}
+	}
+
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, obj != nil
+	}
+	if fn.Pkg() != s.typed.pkg || !fn.Pos().IsValid() {
+		// Calls into the standard library have no chunks to link to
+		return nil, true
+	}
+
+	symbol := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		recvType := recv.Type()
+		if pointer, ok := recvType.(*types.Pointer); ok {
+			recvType = pointer.Elem()
+		}
+		named, ok := recvType.(*types.Named)
+		if !ok || types.IsInterface(named) {
+			// Interface methods have no body to call
+			return nil, true
+		}
+		symbol = named.Obj().Name() + "." + symbol
+	}
+
+	defPos := s.fset.Position(fn.Pos())
+	return &goCallee{symbol: symbol, defFile: defPos.Filename, defLine: defPos.Line}, true
+}
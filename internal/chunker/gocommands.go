@@ -0,0 +1,783 @@
+package chunker
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// cobraImport is the import path of the cobra CLI library
+const cobraImport = "github.com/spf13/cobra"
+
+// goFlagTypes lists the flag types of the flag and pflag packages, and
+// whether their definitions take a default value
+var goFlagTypes = map[string]bool{
+	"Bool": true, "BoolSlice": true, "BoolFunc": false,
+	"String": true, "StringSlice": true, "StringArray": true, "StringToString": true,
+	"Int": true, "Int8": true, "Int16": true, "Int32": true, "Int64": true, "IntSlice": true,
+	"Uint": true, "Uint8": true, "Uint16": true, "Uint32": true, "Uint64": true, "UintSlice": true,
+	"Float32": true, "Float64": true, "Float64Slice": true,
+	"Duration": true, "DurationSlice": true, "Count": false, "Func": false,
+	"IP": true, "IPSlice": true, "IPMask": true, "IPNet": true,
+	"BytesHex": true, "BytesBase64": true, "Text": true,
+}
+
+// pflagTypeNames maps pflag types to the names help output shows for them,
+// where they aren't the type's name in lower camel case
+var pflagTypeNames = map[string]string{
+	"Float64": "float", "Int64": "int", "Uint64": "uint",
+	"BoolSlice": "bools", "StringSlice": "strings", "IntSlice": "ints", "UintSlice": "uints",
+	"IP": "ip", "IPSlice": "ipSlice", "IPMask": "ipMask", "IPNet": "ipNet", "BoolFunc": "boolfunc",
+}
+
+// flagTypeNames maps the types of the flag package to the names its help
+// output shows for them, the others being shown as value
+var flagTypeNames = map[string]string{
+	"Bool": "bool", "BoolFunc": "boolfunc", "Duration": "duration", "Float64": "float",
+	"Int": "int", "Int64": "int", "String": "string", "Uint": "uint", "Uint64": "uint",
+}
+
+// goDurationUnits are the constants of the time package a duration default is written with
+var goDurationUnits = map[string]time.Duration{
+	"Nanosecond": time.Nanosecond, "Microsecond": time.Microsecond, "Millisecond": time.Millisecond,
+	"Second": time.Second, "Minute": time.Minute, "Hour": time.Hour,
+}
+
+// goCommand is a command found in a file, along with what links it to code
+type goCommand struct {
+	info     model.Command
+	funcName string
+	start    token.Pos
+	end      token.Pos
+
+	// runs are the Run and RunE values
+	runs []ast.Expr
+
+	// children are the arguments of AddCommand
+	children []ast.Expr
+}
+
+// collectCommands creates a documentation chunk for each command defined in
+// a file with cobra or the flag package, holding its usage, subcommands and
+// flags the way help output shows them. Command chunks define a
+// "command:name" symbol, and refer to the functions they run, the function
+// defining them and their subcommands
+func (c *GoChunker) collectCommands(fset *token.FileSet, file *ast.File, filePath string, symbolTable *model.SymbolTable) []model.Chunk {
+	dir := filepath.Dir(filePath)
+	var commands []*goCommand
+	vars := make(map[string]*goCommand)
+	literals := make(map[*ast.CompositeLit]*goCommand)
+
+	// lookup finds the command held by a variable of a function or of the package
+	lookup := func(funcName string, expr ast.Expr) *goCommand {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		if command, ok := vars[funcName+"."+ident.Name]; ok {
+			return command
+		}
+		return vars["."+ident.Name]
+	}
+
+	// First pass: commands and the variables holding them
+	for _, decl := range file.Decls {
+		funcName := declFuncName(decl)
+
+		bind := func(varName string, value ast.Expr) {
+			if lit := cobraCommandLiteral(file, value); lit != nil {
+				command := cobraCommand(lit, funcName)
+				literals[lit] = command
+				commands = append(commands, command)
+				vars[funcName+"."+varName] = command
+				return
+			}
+			// Flag sets are commands of their own
+			if call, ok := value.(*ast.CallExpr); ok && isPackageSelector(file, call.Fun, "flag", "NewFlagSet") && len(call.Args) > 0 {
+				if name, ok := constantString(call.Args[0]); ok {
+					command := &goCommand{info: model.Command{Name: name, Use: name}, funcName: funcName, start: call.Pos(), end: call.End()}
+					commands = append(commands, command)
+					vars[funcName+"."+varName] = command
+				}
+			}
+		}
+
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						if ident, ok := lhs.(*ast.Ident); ok {
+							bind(ident.Name, n.Rhs[i])
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				for i, name := range n.Names {
+					if i < len(n.Values) {
+						bind(name.Name, n.Values[i])
+					}
+				}
+			case *ast.CompositeLit:
+				// Commands not held by a variable, e.g. returned or added directly
+				if lit := cobraCommandLiteral(file, n); lit != nil && literals[lit] == nil {
+					command := cobraCommand(lit, funcName)
+					literals[lit] = command
+					commands = append(commands, command)
+				}
+			}
+			return true
+		})
+	}
+
+	// Second pass: flags and subcommands
+	var program *goCommand
+	for _, decl := range file.Decls {
+		funcName := declFuncName(decl)
+
+		ast.Inspect(decl, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			var command *goCommand
+			var flag model.CommandFlag
+			isFlag := false
+			switch x := selector.X.(type) {
+			case *ast.CallExpr:
+				// cmd.Flags().StringVarP(...) and cmd.PersistentFlags()...
+				flags, ok := x.Fun.(*ast.SelectorExpr)
+				if !ok || (flags.Sel.Name != "Flags" && flags.Sel.Name != "PersistentFlags" && flags.Sel.Name != "LocalFlags") {
+					return true
+				}
+				if command = lookup(funcName, flags.X); command == nil {
+					return true
+				}
+				flag, isFlag = c.goFlag(dir, file, selector.Sel.Name, call.Args, true)
+				flag.Persistent = flags.Sel.Name == "PersistentFlags"
+
+			case *ast.Ident:
+				if selector.Sel.Name == "AddCommand" {
+					if command = lookup(funcName, x); command != nil {
+						command.children = append(command.children, call.Args...)
+						command.end = max(command.end, call.End())
+					}
+					return true
+				}
+				if x.Obj == nil && importPathOf(file, x.Name) == "flag" {
+					// Flags of the program itself, named after its directory
+					if program == nil {
+						name := filepath.Base(dir)
+						program = &goCommand{info: model.Command{Name: name, Use: name}, funcName: funcName, start: call.Pos(), end: call.End()}
+					}
+					command = program
+				} else if command = lookup(funcName, x); command == nil {
+					return true
+				}
+				flag, isFlag = c.goFlag(dir, file, selector.Sel.Name, call.Args, false)
+			}
+
+			if isFlag {
+				command.info.Flags = append(command.info.Flags, flag)
+				command.start = min(command.start, call.Pos())
+				command.end = max(command.end, call.End())
+			}
+			return true
+		})
+	}
+	if program != nil && len(program.info.Flags) > 0 {
+		commands = append(commands, program)
+	}
+
+	var chunks []model.Chunk
+	for _, command := range commands {
+		if command.info.Name == "" {
+			continue
+		}
+		chunks = append(chunks, c.commandChunk(fset, file, filePath, command, lookup, literals, symbolTable))
+	}
+	return chunks
+}
+
+// commandChunk creates the chunk documenting a command and records its references
+func (c *GoChunker) commandChunk(fset *token.FileSet, file *ast.File, filePath string, command *goCommand, lookup func(string, ast.Expr) *goCommand, literals map[*ast.CompositeLit]*goCommand, symbolTable *model.SymbolTable) model.Chunk {
+	dir := filepath.Dir(filePath)
+	pkgPath := c.packagePath(dir)
+
+	// Subcommands may be created by constructors and variables of other files
+	type subcommand struct{ name, pkg string }
+	var subcommands []subcommand
+	for _, child := range command.children {
+		sub := subcommand{pkg: pkgPath}
+		switch ch := child.(type) {
+		case *ast.Ident:
+			if childCommand := lookup(command.funcName, ch); childCommand != nil {
+				sub.name = childCommand.info.Name
+			} else {
+				sub.name = c.commandNames(dir)[ch.Name]
+			}
+		case *ast.UnaryExpr:
+			if lit := cobraCommandLiteral(file, ch); lit != nil && literals[lit] != nil {
+				sub.name = literals[lit].info.Name
+			}
+		case *ast.CallExpr:
+			switch fun := ch.Fun.(type) {
+			case *ast.Ident:
+				sub.name = c.commandNames(dir)[fun.Name]
+			case *ast.SelectorExpr:
+				if x, ok := fun.X.(*ast.Ident); ok && x.Obj == nil {
+					if path := importPathOf(file, x.Name); path != "" {
+						if local := c.resolveImport(dir, path); local != "" {
+							sub.name = c.commandNames(local)[fun.Sel.Name]
+							sub.pkg = c.canonicalImport(dir, path)
+						}
+					}
+				}
+			}
+		}
+		if sub.name != "" {
+			subcommands = append(subcommands, sub)
+			command.info.Subcommands = append(command.info.Subcommands, sub.name)
+		}
+	}
+
+	content := renderCommand(command.info)
+	symbol := "command:" + command.info.Name
+	chunk := model.Chunk{
+		ID:         util.GenerateID(filePath, content),
+		FilePath:   filePath,
+		StartLine:  fset.Position(command.start).Line,
+		EndLine:    fset.Position(command.end).Line,
+		Content:    content,
+		Language:   "go",
+		Symbols:    []string{symbol},
+		Kind:       "command",
+		Command:    &command.info,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+
+	symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+		Name:      symbol,
+		ChunkID:   chunk.ID,
+		FilePath:  filePath,
+		StartLine: chunk.StartLine,
+		EndLine:   chunk.EndLine,
+		Type:      "command",
+	})
+
+	line := chunk.StartLine
+	if command.funcName != "" {
+		c.addQualifiedReference(symbolTable, chunk, command.funcName, pkgPath, line)
+	}
+	for _, sub := range subcommands {
+		c.addQualifiedReference(symbolTable, chunk, "command:"+sub.name, sub.pkg, line)
+	}
+
+	// The functions a command runs, or those its Run function literal calls
+	for _, run := range command.runs {
+		var targets []ast.Expr
+		if lit, ok := run.(*ast.FuncLit); ok {
+			ast.Inspect(lit.Body, func(node ast.Node) bool {
+				if call, ok := node.(*ast.CallExpr); ok {
+					targets = append(targets, call.Fun)
+				}
+				return true
+			})
+		} else {
+			targets = append(targets, run)
+		}
+
+		for _, target := range targets {
+			switch t := target.(type) {
+			case *ast.Ident:
+				if t.Obj == nil || t.Obj.Kind == ast.Fun {
+					c.addQualifiedReference(symbolTable, chunk, t.Name, pkgPath, line)
+				}
+			case *ast.SelectorExpr:
+				if x, ok := t.X.(*ast.Ident); ok && x.Obj == nil {
+					if path := importPathOf(file, x.Name); path != "" {
+						c.addQualifiedReference(symbolTable, chunk, t.Sel.Name, c.canonicalImport(dir, path), line)
+					}
+				}
+			}
+		}
+	}
+
+	return chunk
+}
+
+// commandNames returns the names of the cobra commands created by the
+// functions and package-level variables of the package in a directory
+func (c *GoChunker) commandNames(dir string) map[string]string {
+	if names, ok := c.commands[dir]; ok {
+		return names
+	}
+	if c.commands == nil {
+		c.commands = make(map[string]map[string]string)
+	}
+
+	names := make(map[string]string)
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			ast.Inspect(decl, func(node ast.Node) bool {
+				switch n := node.(type) {
+				case *ast.FuncDecl:
+					ast.Inspect(n.Body, func(inner ast.Node) bool {
+						if lit, ok := inner.(*ast.CompositeLit); ok && cobraCommandLiteral(file, lit) != nil && names[n.Name.Name] == "" {
+							names[n.Name.Name] = cobraCommand(lit, "").info.Name
+						}
+						return true
+					})
+					return false
+				case *ast.ValueSpec:
+					for i, ident := range n.Names {
+						if i < len(n.Values) {
+							if lit := cobraCommandLiteral(file, n.Values[i]); lit != nil {
+								names[ident.Name] = cobraCommand(lit, "").info.Name
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	c.commands[dir] = names
+	return names
+}
+
+// cobraCommandLiteral returns the cobra.Command literal an expression is, or nil
+func cobraCommandLiteral(file *ast.File, expr ast.Expr) *ast.CompositeLit {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok || !isPackageSelector(file, lit.Type, cobraImport, "Command") {
+		return nil
+	}
+	return lit
+}
+
+// cobraCommand reads the fields of a cobra.Command literal. The command is
+// named by the first word of its Use line
+func cobraCommand(lit *ast.CompositeLit, funcName string) *goCommand {
+	command := &goCommand{funcName: funcName, start: lit.Pos(), end: lit.End()}
+	for _, elt := range lit.Elts {
+		field, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := field.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		value, _ := constantString(field.Value)
+		switch key.Name {
+		case "Use":
+			command.info.Use = value
+			if fields := strings.Fields(value); len(fields) > 0 {
+				command.info.Name = fields[0]
+			}
+		case "Short":
+			command.info.Short = value
+		case "Long":
+			command.info.Long = value
+		case "Run", "RunE":
+			command.runs = append(command.runs, field.Value)
+		}
+	}
+	return command
+}
+
+// goFlag reads a flag definition such as StringVarP(&v, "name", "n",
+// "default", "usage"). Shorthands only exist in pflag
+func (c *GoChunker) goFlag(dir string, file *ast.File, method string, args []ast.Expr, shorthands bool) (model.CommandFlag, bool) {
+	typ, hasVar, hasShorthand := method, false, false
+	switch {
+	case shorthands && strings.HasSuffix(method, "VarP"):
+		typ, hasVar, hasShorthand = strings.TrimSuffix(method, "VarP"), true, true
+	case strings.HasSuffix(method, "Var"):
+		typ, hasVar = strings.TrimSuffix(method, "Var"), true
+	case shorthands && strings.HasSuffix(method, "P") && goFlagTypes[strings.TrimSuffix(method, "P")]:
+		typ, hasShorthand = strings.TrimSuffix(method, "P"), true
+	}
+
+	// Var defines a flag of a custom flag.Value type
+	hasDefault, ok := goFlagTypes[typ]
+	if typ != "" && !ok {
+		return model.CommandFlag{}, false
+	}
+
+	i := 0
+	next := func() (ast.Expr, bool) {
+		if i >= len(args) {
+			return nil, false
+		}
+		i++
+		return args[i-1], true
+	}
+	if hasVar {
+		next()
+	}
+
+	var flag model.CommandFlag
+	nameArg, ok := next()
+	if !ok {
+		return flag, false
+	}
+	if flag.Name, ok = constantString(nameArg); !ok {
+		return flag, false
+	}
+	if hasShorthand {
+		if arg, ok := next(); ok {
+			flag.Shorthand, _ = constantString(arg)
+		}
+	}
+	if hasDefault {
+		if arg, ok := next(); ok {
+			flag.Default = c.goFlagDefault(dir, file, typ, arg)
+		}
+	}
+	if arg, ok := next(); ok {
+		flag.Usage, _ = constantString(arg)
+	}
+
+	flag.Type = "value"
+	switch {
+	case typ == "":
+	case shorthands && pflagTypeNames[typ] != "":
+		flag.Type = pflagTypeNames[typ]
+	case shorthands:
+		first, size := utf8.DecodeRuneInString(typ)
+		flag.Type = string(unicode.ToLower(first)) + typ[size:]
+	case flagTypeNames[typ] != "":
+		flag.Type = flagTypeNames[typ]
+	}
+	return flag, true
+}
+
+// goFlagDefault returns a flag's default the way help output shows it.
+// Zero values, which help output leaves out, and defaults that aren't made
+// of constants of the package or of in-tree packages are left empty
+func (c *GoChunker) goFlagDefault(dir string, file *ast.File, typ string, arg ast.Expr) string {
+	// Slices show their elements, such as [a,b]
+	if strings.HasSuffix(typ, "Slice") || strings.HasSuffix(typ, "Array") {
+		value, litDir, litFile := c.goDeclaredValue(dir, file, arg, 0)
+		lit, ok := value.(*ast.CompositeLit)
+		if !ok || len(lit.Elts) == 0 {
+			return ""
+		}
+		elements := make([]string, len(lit.Elts))
+		for i, elt := range lit.Elts {
+			value, ok := c.goConstant(litDir, litFile, elt, 0)
+			if !ok {
+				return ""
+			}
+			if elements[i] = value.ExactString(); value.Kind() == constant.String {
+				elements[i] = constant.StringVal(value)
+			}
+		}
+		return "[" + strings.Join(elements, ",") + "]"
+	}
+
+	value, ok := c.goConstant(dir, file, arg, 0)
+	if !ok {
+		return ""
+	}
+	if typ == "Duration" {
+		if n, exact := constant.Int64Val(constant.ToInt(value)); exact && n != 0 {
+			return time.Duration(n).String()
+		}
+		return ""
+	}
+
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Bool:
+		if constant.BoolVal(value) {
+			return "true"
+		}
+	case constant.Int:
+		if constant.Sign(value) != 0 {
+			return value.ExactString()
+		}
+	case constant.Float:
+		if f, _ := constant.Float64Val(value); f != 0 {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	return ""
+}
+
+// goMaxValueDepth bounds how many declarations are followed to evaluate a value
+const goMaxValueDepth = 8
+
+// goDeclaredValue follows identifiers and selectors to the expression the
+// package-level constant or variable they name is declared with, in the
+// file's package or an in-tree package it imports, along with the directory
+// and file declaring it, for evaluating the names it uses in turn. Other
+// expressions are returned as they are, and declarations of the file that
+// aren't at package level give nil
+func (c *GoChunker) goDeclaredValue(dir string, file *ast.File, expr ast.Expr, depth int) (ast.Expr, string, *ast.File) {
+	if depth > goMaxValueDepth {
+		return nil, "", nil
+	}
+
+	var decl goDecl
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return c.goDeclaredValue(dir, file, e.X, depth)
+	case *ast.Ident:
+		if e.Obj != nil {
+			// Declared in the file, where only variables at package level
+			// still hold the value they're declared with
+			spec, ok := e.Obj.Decl.(*ast.ValueSpec)
+			if !ok || (e.Obj.Kind != ast.Con && !isTopLevelSpec(file, spec)) {
+				return nil, "", nil
+			}
+			for i, name := range spec.Names {
+				if name.Name == e.Name && i < len(spec.Values) {
+					return c.goDeclaredValue(dir, file, spec.Values[i], depth+1)
+				}
+			}
+			return nil, "", nil
+		}
+		// Declared in another file of the package, or predeclared such as true
+		var ok bool
+		if decl, ok = c.packageValues(dir)[e.Name]; !ok {
+			return expr, dir, file
+		}
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return expr, dir, file
+		}
+		path := importPathOf(file, x.Name)
+		local := c.resolveImport(dir, path)
+		if path == "" || local == "" {
+			return expr, dir, file
+		}
+		if decl, ok = c.packageValues(local)[e.Sel.Name]; !ok {
+			return nil, "", nil
+		}
+	default:
+		return expr, dir, file
+	}
+	return c.goDeclaredValue(decl.dir, decl.file, decl.value, depth+1)
+}
+
+// goDecl is the value a package-level constant or variable is declared with
+type goDecl struct {
+	value ast.Expr
+	dir   string
+	file  *ast.File
+}
+
+// packageValues returns the values of the package-level constants and
+// variables of the package in a directory, by name
+func (c *GoChunker) packageValues(dir string) map[string]goDecl {
+	if values, ok := c.values[dir]; ok {
+		return values
+	}
+	if c.values == nil {
+		c.values = make(map[string]map[string]goDecl)
+	}
+
+	values := make(map[string]goDecl)
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || (genDecl.Tok != token.CONST && genDecl.Tok != token.VAR) {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for i, ident := range valueSpec.Names {
+					if i < len(valueSpec.Values) {
+						values[ident.Name] = goDecl{value: valueSpec.Values[i], dir: dir, file: file}
+					}
+				}
+			}
+		}
+	}
+
+	c.values[dir] = values
+	return values
+}
+
+// isTopLevelSpec checks if a value spec is declared at the top level of a file
+func isTopLevelSpec(file *ast.File, spec *ast.ValueSpec) bool {
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && slices.Contains(genDecl.Specs, ast.Spec(spec)) {
+			return true
+		}
+	}
+	return false
+}
+
+// goConstant evaluates a constant expression made of literals, the
+// constants and variables goDeclaredValue finds and the units of the time
+// package
+func (c *GoChunker) goConstant(dir string, file *ast.File, expr ast.Expr, depth int) (value constant.Value, ok bool) {
+	// Operations on values of mismatched kinds panic
+	defer func() {
+		if recover() != nil {
+			value, ok = nil, false
+		}
+	}()
+
+	expr, dir, file = c.goDeclaredValue(dir, file, expr, depth)
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		value = constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		return value, value.Kind() != constant.Unknown
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			return constant.MakeBool(e.Name == "true"), true
+		}
+	case *ast.SelectorExpr:
+		// Durations are written with the units of the time package
+		if x, ok := e.X.(*ast.Ident); ok && x.Obj == nil && importPathOf(file, x.Name) == "time" {
+			if unit, ok := goDurationUnits[e.Sel.Name]; ok {
+				return constant.MakeInt64(int64(unit)), true
+			}
+		}
+	case *ast.UnaryExpr:
+		x, ok := c.goConstant(dir, file, e.X, depth+1)
+		if !ok {
+			return nil, false
+		}
+		return constant.UnaryOp(e.Op, x, 0), true
+	case *ast.BinaryExpr:
+		x, ok := c.goConstant(dir, file, e.X, depth+1)
+		if !ok {
+			return nil, false
+		}
+		y, ok := c.goConstant(dir, file, e.Y, depth+1)
+		if !ok {
+			return nil, false
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			shift, exact := constant.Uint64Val(y)
+			if !exact {
+				return nil, false
+			}
+			return constant.Shift(x, e.Op, uint(shift)), true
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y)), true
+		case token.QUO:
+			// Integer constants divide without a remainder
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y), true
+			}
+		}
+		return constant.BinaryOp(x, e.Op, y), true
+	}
+	return nil, false
+}
+
+// renderCommand writes a command the way help output shows it
+func renderCommand(command model.Command) string {
+	var sb strings.Builder
+	sb.WriteString(command.Use + "\n")
+	if command.Short != "" {
+		sb.WriteString("\n" + command.Short + "\n")
+	}
+	if command.Long != "" {
+		sb.WriteString("\n" + strings.TrimSpace(command.Long) + "\n")
+	}
+
+	if len(command.Subcommands) > 0 {
+		sb.WriteString("\nSubcommands:\n")
+		for _, name := range command.Subcommands {
+			sb.WriteString("  " + name + "\n")
+		}
+	}
+
+	for _, persistent := range []bool{false, true} {
+		title := "\nFlags:\n"
+		if persistent {
+			title = "\nPersistent Flags:\n"
+		}
+
+		w := tabwriter.NewWriter(&sb, 0, 8, 3, ' ', 0)
+		for _, flag := range command.Flags {
+			if flag.Persistent != persistent {
+				continue
+			}
+			if title != "" {
+				sb.WriteString(title)
+				title = ""
+			}
+			names := "      --" + flag.Name
+			if flag.Shorthand != "" {
+				names = "  -" + flag.Shorthand + ", --" + flag.Name
+			}
+			usage := flag.Usage
+			switch {
+			case flag.Default != "" && flag.Type == "string":
+				usage += fmt.Sprintf(" (default %q)", flag.Default)
+			case flag.Default != "":
+				usage += " (default " + flag.Default + ")"
+			}
+			// Like help output, boolean flags don't show their type
+			if flag.Type != "bool" && flag.Type != "boolfunc" {
+				names += " " + flag.Type
+			}
+			fmt.Fprintf(w, "%s\t%s\n", names, usage)
+		}
+		w.Flush()
+	}
+
+	return sb.String()
+}
+
+// declFuncName returns the symbol of a function declaration, or an empty
+// string for other declarations
+func declFuncName(decl ast.Decl) string {
+	if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+		return funcSymbol(funcDecl)
+	}
+	return ""
+}
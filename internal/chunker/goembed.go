@@ -0,0 +1,118 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// addEmbedReferences records a file reference from a var declaration to
+// each file its //go:embed directives match
+func (c *GoChunker) addEmbedReferences(fset *token.FileSet, decl *ast.GenDecl, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	groups := []*ast.CommentGroup{decl.Doc}
+	for _, spec := range decl.Specs {
+		if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+			groups = append(groups, valueSpec.Doc)
+		}
+	}
+
+	dir := filepath.Dir(chunk.FilePath)
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			args, ok := strings.CutPrefix(comment.Text, "//go:embed")
+			if !ok || (args != "" && args[0] != ' ' && args[0] != '\t') {
+				continue
+			}
+			line := fset.Position(comment.Pos()).Line
+			for _, pattern := range embedPatterns(args) {
+				for _, path := range embeddedFiles(dir, pattern) {
+					symbolTable.AddFileReference(path, model.SymbolReference{
+						Name:     path,
+						ChunkID:  chunk.ID,
+						FilePath: chunk.FilePath,
+						Line:     line,
+					})
+				}
+			}
+		}
+	}
+}
+
+// embedPatterns splits the arguments of a //go:embed directive, which are
+// separated by spaces and may be quoted
+func embedPatterns(args string) []string {
+	var patterns []string
+	for args = strings.TrimSpace(args); args != ""; args = strings.TrimSpace(args) {
+		switch args[0] {
+		case '"', '`':
+			end := strings.IndexByte(args[1:], args[0])
+			if end < 0 {
+				return patterns
+			}
+			quoted := args[:end+2]
+			args = args[end+2:]
+			if pattern, err := strconv.Unquote(quoted); err == nil {
+				patterns = append(patterns, pattern)
+			}
+		default:
+			end := strings.IndexAny(args, " \t")
+			if end < 0 {
+				end = len(args)
+			}
+			patterns = append(patterns, args[:end])
+			args = args[end:]
+		}
+	}
+	return patterns
+}
+
+// embeddedFiles returns the files a //go:embed pattern matches in a package
+// directory. Matched directories embed their files recursively, except for
+// names starting with . or _ unless the pattern has the all: prefix
+func embeddedFiles(dir string, pattern string) []string {
+	pattern, all := strings.CutPrefix(pattern, "all:")
+
+	matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, match)
+			continue
+		}
+
+		filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := entry.Name()
+			if path != match && !all && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	return files
+}
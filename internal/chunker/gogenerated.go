@@ -0,0 +1,233 @@
+package chunker
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// Policies for Go files with a "Code generated ... DO NOT EDIT." header
+const (
+	// GeneratedKeep chunks generated files like any other file
+	GeneratedKeep = "keep"
+
+	// GeneratedSkip leaves generated files out
+	GeneratedSkip = "skip"
+
+	// GeneratedSummarize collapses each generated file into a single chunk
+	// listing the signatures of its exported declarations
+	GeneratedSummarize = "summarize"
+)
+
+// goPrinter prints declarations the way gofmt does
+var goPrinter = printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+
+// GeneratedPolicies lists the valid policies for generated Go files
+var GeneratedPolicies = []string{GeneratedKeep, GeneratedSkip, GeneratedSummarize}
+
+// summarizeGenerated creates a single chunk for a generated file, holding
+// its header, package clause and the signatures of its exported
+// declarations. Function bodies, unexported declarations, struct tags and
+// values other than literals are left out
+func (c *GoChunker) summarizeGenerated(fset *token.FileSet, file *ast.File, filePath string, content []byte, imports []string, symbolTable *model.SymbolTable) model.Chunk {
+	var summary bytes.Buffer
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "// Code generated ") {
+				summary.WriteString(comment.Text + "\n\n")
+			}
+		}
+	}
+	summary.WriteString("package " + file.Name.Name + "\n")
+
+	symbols, types := writeExportedDecls(&summary, fset, file.Decls)
+
+	summaryContent := summary.String()
+	lineCount := strings.Count(string(content), "\n")
+	if !strings.HasSuffix(string(content), "\n") {
+		lineCount++
+	}
+
+	chunk := model.Chunk{
+		ID:         util.GenerateID(filePath, summaryContent),
+		FilePath:   filePath,
+		StartLine:  1,
+		EndLine:    lineCount,
+		Content:    summaryContent,
+		Language:   "go",
+		Symbols:    symbols,
+		Imports:    imports,
+		Kind:       "summary",
+		TokenCount: util.EstimateTokenCount(summaryContent),
+	}
+	setMetadata(&chunk, "generated", "true")
+	if expr := buildConstraint(file); expr != "" {
+		setMetadata(&chunk, "build", expr)
+	}
+
+	for _, symbol := range symbols {
+		symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+			Name:      symbol,
+			ChunkID:   chunk.ID,
+			FilePath:  filePath,
+			StartLine: chunk.StartLine,
+			EndLine:   chunk.EndLine,
+			Type:      types[symbol],
+		})
+	}
+
+	return chunk
+}
+
+// writeExportedDecls writes the signatures of the exported declarations
+// among decls, and returns their symbols along with each symbol's type
+func writeExportedDecls(buf *bytes.Buffer, fset *token.FileSet, decls []ast.Decl) ([]string, map[string]string) {
+	var symbols []string
+	types := make(map[string]string)
+
+	for _, decl := range decls {
+		var node ast.Node
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			receiverType := receiverTypeName(d)
+			if !d.Name.IsExported() || (d.Recv != nil && !ast.IsExported(receiverType)) {
+				continue
+			}
+			signature := *d
+			signature.Doc = nil
+			signature.Body = nil
+			node = &signature
+			symbols = append(symbols, funcSymbol(d))
+			types[funcSymbol(d)] = "function"
+
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE && d.Tok != token.CONST && d.Tok != token.VAR {
+				continue
+			}
+			signature := &ast.GenDecl{Tok: d.Tok, Lparen: d.Lparen, Rparen: d.Rparen}
+			for _, spec := range d.Specs {
+				if spec := exportedSpec(spec); spec != nil {
+					signature.Specs = append(signature.Specs, spec)
+				}
+			}
+			if len(signature.Specs) == 0 {
+				continue
+			}
+			if len(signature.Specs) == 1 {
+				signature.Lparen, signature.Rparen = token.NoPos, token.NoPos
+			}
+			for _, spec := range signature.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, s.Name.Name)
+					types[s.Name.Name] = "type"
+					if _, ok := s.Type.(*ast.InterfaceType); ok {
+						types[s.Name.Name] = "interface"
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						symbols = append(symbols, name.Name)
+						types[name.Name] = d.Tok.String()
+					}
+				}
+			}
+			node = signature
+		}
+
+		if node != nil {
+			buf.WriteString("\n")
+			buf.WriteString(printSignature(fset, node))
+		}
+	}
+
+	return symbols, types
+}
+
+// printSignature prints a declaration stripped of its comments and bodies.
+// Blank lines left behind by what was dropped are removed, and the result is
+// formatted again to realign it
+func printSignature(fset *token.FileSet, node ast.Node) string {
+	var printed bytes.Buffer
+	goPrinter.Fprint(&printed, fset, node)
+
+	var compact strings.Builder
+	for _, line := range strings.Split(printed.String(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			compact.WriteString(line + "\n")
+		}
+	}
+
+	const header = "package p\n\n"
+	if formatted, err := format.Source([]byte(header + compact.String())); err == nil {
+		return strings.TrimPrefix(string(formatted), header)
+	}
+	return compact.String()
+}
+
+// exportedSpec returns the signature of a type, const or var spec with only
+// its exported names, or nil if it has none
+func exportedSpec(spec ast.Spec) ast.Spec {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		if !s.Name.IsExported() {
+			return nil
+		}
+		signature := *s
+		signature.Doc, signature.Comment = nil, nil
+		if structType, ok := s.Type.(*ast.StructType); ok {
+			signature.Type = exportedFields(structType)
+		}
+		return &signature
+
+	case *ast.ValueSpec:
+		signature := &ast.ValueSpec{Type: s.Type}
+		for i, name := range s.Names {
+			if !name.IsExported() {
+				continue
+			}
+			signature.Names = append(signature.Names, name)
+			// Literal values are kept, such as enum constants, but not large tables
+			if i < len(s.Values) {
+				if _, ok := s.Values[i].(*ast.BasicLit); ok {
+					signature.Values = append(signature.Values, s.Values[i])
+				}
+			}
+		}
+		if len(signature.Names) == 0 {
+			return nil
+		}
+		if len(signature.Values) != len(signature.Names) {
+			signature.Values = nil
+		}
+		return signature
+	}
+	return nil
+}
+
+// exportedFields returns a struct type with only its exported and embedded
+// fields, without their tags
+func exportedFields(structType *ast.StructType) *ast.StructType {
+	fields := &ast.FieldList{Opening: structType.Fields.Opening, Closing: structType.Fields.Closing}
+	for _, field := range structType.Fields.List {
+		var names []*ast.Ident
+		for _, name := range field.Names {
+			if name.IsExported() {
+				names = append(names, name)
+			}
+		}
+		if len(field.Names) > 0 && len(names) == 0 {
+			continue
+		}
+		fields.List = append(fields.List, &ast.Field{Names: names, Type: field.Type})
+	}
+	return &ast.StructType{Struct: structType.Struct, Fields: fields}
+}
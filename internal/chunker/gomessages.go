@@ -0,0 +1,245 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// goMessageFunc describes a function taking an error or log message: the
+// index of the message argument, and whether it's a format string
+type goMessageFunc struct {
+	arg    int
+	format bool
+}
+
+// goMessageFuncs lists the functions taking a message by import path. The
+// methods of the Logger types of log and log/slog take them like the package
+// functions of the same names
+var goMessageFuncs = map[string]map[string]goMessageFunc{
+	"errors": {"New": {}},
+	"fmt":    {"Errorf": {format: true}, "Sprintf": {format: true}},
+	"log": {
+		"Print": {}, "Printf": {format: true}, "Println": {},
+		"Fatal": {}, "Fatalf": {format: true}, "Fatalln": {},
+		"Panic": {}, "Panicf": {format: true}, "Panicln": {},
+	},
+	"log/slog": {
+		"Debug": {}, "Info": {}, "Warn": {}, "Error": {},
+		"DebugContext": {arg: 1}, "InfoContext": {arg: 1}, "WarnContext": {arg: 1}, "ErrorContext": {arg: 1},
+		"Log": {arg: 2},
+	},
+}
+
+// goFormatVerb matches a fmt verb with its flags, width, precision and
+// argument index, or an escaped percent sign
+var goFormatVerb = regexp.MustCompile(`%%|%[-+# 0]*(\[\d+\])?(\d+|\*)?(\.(\d+|\*)?)?(\[\d+\])?[a-zA-Z]`)
+
+// collectMessages records the error and log messages produced by each
+// chunk's code as templates, with format verbs and values that aren't
+// constant replaced by wildcards, and the lines of the calls producing them.
+// Calls of logger methods are only recognized in type-checked packages
+func (c *GoChunker) collectMessages(fset *token.FileSet, file *ast.File, filePath string, typed *goPackage, chunks []model.Chunk) {
+	var info *types.Info
+	if typed != nil {
+		fset, file, info = c.typeFset, typed.files[filePath], typed.info
+	}
+
+	chunkAt := func(line int) int {
+		for i, chunk := range chunks {
+			if line >= chunk.StartLine && line <= chunk.EndLine && chunk.Kind != "package" {
+				return i
+			}
+		}
+		return -1
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		message, ok := goMessage(file, info, call)
+		if !ok {
+			return true
+		}
+		line := fset.Position(call.Pos()).Line
+		i := chunkAt(line)
+		if i < 0 {
+			return true
+		}
+		j := slices.IndexFunc(chunks[i].Messages, func(m model.Message) bool { return m.Template == message })
+		if j < 0 {
+			chunks[i].Messages = append(chunks[i].Messages, model.Message{Template: message})
+			j = len(chunks[i].Messages) - 1
+		}
+		if !slices.Contains(chunks[i].Messages[j].Lines, line) {
+			chunks[i].Messages[j].Lines = append(chunks[i].Messages[j].Lines, line)
+		}
+		return true
+	})
+}
+
+// goMessage returns the message template of a call producing an error or
+// log message. fmt.Sprintf only produces messages for panic
+func goMessage(file *ast.File, info *types.Info, call *ast.CallExpr) (string, bool) {
+	if fun, ok := call.Fun.(*ast.Ident); ok {
+		// The panic builtin, with a message or a formatted one
+		if fun.Name != "panic" || !isBuiltin(info, fun) || len(call.Args) != 1 {
+			return "", false
+		}
+		if inner, ok := call.Args[0].(*ast.CallExpr); ok {
+			if pkg, name := goMessageCallee(file, info, inner.Fun); pkg == "fmt" && name == "Sprintf" {
+				return goFuncMessage(file, info, inner)
+			}
+		}
+		return messageTemplate(call.Args[0], false)
+	}
+
+	if pkg, name := goMessageCallee(file, info, call.Fun); pkg == "fmt" && name == "Sprintf" {
+		return "", false
+	}
+	return goFuncMessage(file, info, call)
+}
+
+// goFuncMessage returns the message template of a call to one of goMessageFuncs
+func goFuncMessage(file *ast.File, info *types.Info, call *ast.CallExpr) (string, bool) {
+	pkg, name := goMessageCallee(file, info, call.Fun)
+	fn, ok := goMessageFuncs[pkg][name]
+	if !ok || fn.arg >= len(call.Args) {
+		return "", false
+	}
+	return messageTemplate(call.Args[fn.arg], fn.format)
+}
+
+// goMessageCallee returns the import path and name of the package function,
+// or of the method of a Logger type, that a call expression calls. Without
+// type information only package functions are known
+func goMessageCallee(file *ast.File, info *types.Info, fun ast.Expr) (string, string) {
+	selector, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+
+	if info != nil {
+		if selection, ok := info.Selections[selector]; ok {
+			method, ok := selection.Obj().(*types.Func)
+			if !ok || selection.Kind() != types.MethodVal {
+				return "", ""
+			}
+			recv := method.Type().(*types.Signature).Recv()
+			if recv == nil {
+				return "", ""
+			}
+			recvType := recv.Type()
+			if pointer, ok := recvType.(*types.Pointer); ok {
+				recvType = pointer.Elem()
+			}
+			named, ok := recvType.(*types.Named)
+			if !ok || named.Obj().Pkg() == nil || named.Obj().Name() != "Logger" {
+				return "", ""
+			}
+			return named.Obj().Pkg().Path(), method.Name()
+		}
+		if x, ok := selector.X.(*ast.Ident); ok {
+			if pkgName, ok := info.Uses[x].(*types.PkgName); ok {
+				return pkgName.Imported().Path(), selector.Sel.Name
+			}
+		}
+		return "", ""
+	}
+
+	x, ok := selector.X.(*ast.Ident)
+	if !ok || x.Obj != nil {
+		return "", ""
+	}
+	return importPathOf(file, x.Name), selector.Sel.Name
+}
+
+// isBuiltin reports whether an identifier refers to a builtin rather than to
+// a declaration shadowing it
+func isBuiltin(info *types.Info, ident *ast.Ident) bool {
+	if info != nil {
+		_, ok := info.Uses[ident].(*types.Builtin)
+		return ok
+	}
+	return ident.Obj == nil
+}
+
+// messageTemplate turns a constant message into a template, replacing
+// format verbs with wildcards
+func messageTemplate(arg ast.Expr, format bool) (string, bool) {
+	message, ok := constantText(arg, model.MessageWildcard)
+	if !ok {
+		return "", false
+	}
+	if format {
+		message = goFormatVerb.ReplaceAllStringFunc(message, func(verb string) string {
+			if verb == "%%" {
+				return "%"
+			}
+			return model.MessageWildcard
+		})
+	}
+	message = strings.TrimSpace(message)
+
+	// Messages made only of wildcards can't identify their source
+	if strings.Trim(message, model.MessageWildcard+": ") == "" {
+		return "", false
+	}
+	return message, true
+}
+
+// constantString returns the value of a string literal or of a concatenation
+// involving one, with the parts that aren't literals replaced by *
+func constantString(expr ast.Expr) (string, bool) {
+	return constantText(expr, "*")
+}
+
+// constantText returns the value of a string literal or of a concatenation
+// involving one, with the parts that aren't literals replaced by a wildcard
+func constantText(expr ast.Expr, wildcard string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return value, err == nil
+	case *ast.ParenExpr:
+		return constantText(e.X, wildcard)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		left, leftOK := constantText(e.X, wildcard)
+		right, rightOK := constantText(e.Y, wildcard)
+		if !leftOK && !rightOK {
+			return "", false
+		}
+		if !leftOK {
+			left = wildcard
+		}
+		if !rightOK {
+			right = wildcard
+		}
+		return left + right, true
+	}
+	return "", false
+}
+
+// isPackageSelector reports whether an expression is pkg.name for the package with the given import path
+func isPackageSelector(file *ast.File, expr ast.Expr, importPath string, name string) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != name {
+		return false
+	}
+	x, ok := selector.X.(*ast.Ident)
+	return ok && x.Obj == nil && importPathOf(file, x.Name) == importPath
+}
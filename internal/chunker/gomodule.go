@@ -0,0 +1,253 @@
+package chunker
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// goModule is a module declared by a go.mod file, with the local
+// replacements of its go.mod and the modules and replacements of the go.work
+// workspace containing it
+type goModule struct {
+	path string
+	dir  string
+
+	// replaces maps a module path to the local directory providing it
+	replaces map[string]string
+}
+
+// moduleOf returns the module containing a directory, from the nearest
+// go.mod above it within the chunking root, or nil if there is none
+func (c *GoChunker) moduleOf(dir string) *goModule {
+	if c.modules == nil {
+		c.modules = make(map[string]*goModule)
+	}
+	if module, ok := c.modules[dir]; ok {
+		return module
+	}
+
+	var module *goModule
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		module = &goModule{dir: dir, replaces: make(map[string]string)}
+		module.path = goModulePath(dir)
+		for _, directive := range goModDirectives(data) {
+			if directive[0] == "replace" {
+				addGoReplace(module.replaces, dir, directive[1:])
+			}
+		}
+		if work := findGoWork(dir, c.rootDir); work != "" {
+			data, _ := os.ReadFile(work)
+			workDir := filepath.Dir(work)
+			for _, directive := range goModDirectives(data) {
+				switch directive[0] {
+				case "replace":
+					addGoReplace(module.replaces, workDir, directive[1:])
+				case "use":
+					// Workspace modules are imported from their directories
+					if len(directive) > 1 {
+						useDir := filepath.Join(workDir, directive[1])
+						if path := goModulePath(useDir); path != "" && useDir != dir {
+							module.replaces[path] = useDir
+						}
+					}
+				}
+			}
+		}
+	} else if parent := filepath.Dir(dir); parent != dir && inRootDir(parent, c.rootDir) {
+		module = c.moduleOf(parent)
+	}
+
+	c.modules[dir] = module
+	return module
+}
+
+// packagePath returns the import path of the package in a directory or,
+// outside of a module, the directory relative to the chunking root
+func (c *GoChunker) packagePath(dir string) string {
+	module := c.moduleOf(dir)
+	if module == nil || module.path == "" {
+		if rel, err := filepath.Rel(c.rootDir, dir); c.rootDir != "" && err == nil && inRootDir(dir, c.rootDir) {
+			return filepath.ToSlash(rel)
+		}
+		return dir
+	}
+
+	rel, err := filepath.Rel(module.dir, dir)
+	if err != nil {
+		return dir
+	}
+	if rel == "." {
+		return module.path
+	}
+	return module.path + "/" + filepath.ToSlash(rel)
+}
+
+// resolveImport maps an import path, as seen from a directory, to the local
+// directory of the package if it's in the tree. Imports of modules replaced by
+// local directories resolve to the replacement
+func (c *GoChunker) resolveImport(dir string, importPath string) string {
+	module := c.moduleOf(dir)
+	if module == nil {
+		return ""
+	}
+
+	// The longest replaced module path wins, as for nested modules
+	longest := ""
+	for from := range module.replaces {
+		if _, ok := cutPathPrefix(importPath, from); ok && len(from) > len(longest) {
+			longest = from
+		}
+	}
+	if longest != "" {
+		rest, _ := cutPathPrefix(importPath, longest)
+		return filepath.Join(module.replaces[longest], filepath.FromSlash(rest))
+	}
+	if rest, ok := cutPathPrefix(importPath, module.path); ok && module.path != "" {
+		return filepath.Join(module.dir, filepath.FromSlash(rest))
+	}
+	return ""
+}
+
+// canonicalImport returns the import path a package declares for itself,
+// which differs from the one it's imported by when its module is replaced
+func (c *GoChunker) canonicalImport(dir string, importPath string) string {
+	if local := c.resolveImport(dir, importPath); local != "" {
+		return c.packagePath(local)
+	}
+	return importPath
+}
+
+// recordPackage maps the file's package and the in-tree packages it imports
+// to their directories, and qualifies its chunks and definitions with the
+// package's import path. Files outside of a module are left unqualified
+func (c *GoChunker) recordPackage(filePath string, imports []string, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	dir := filepath.Dir(filePath)
+	if module := c.moduleOf(dir); module == nil || module.path == "" {
+		return
+	}
+
+	pkgPath := c.packagePath(dir)
+	symbolTable.AddPackage(pkgPath, dir)
+	for _, imp := range imports {
+		if local := c.resolveImport(dir, imp); local != "" {
+			symbolTable.AddPackage(imp, local)
+		}
+	}
+
+	for i := range chunks {
+		setMetadata(&chunks[i], "package", pkgPath)
+		for _, symbol := range chunks[i].Symbols {
+			defs := symbolTable.Definitions[symbol]
+			for j := range defs {
+				if defs[j].ChunkID == chunks[i].ID {
+					defs[j].Package = pkgPath
+				}
+			}
+		}
+	}
+}
+
+// goModDirectives splits a go.mod or go.work file into directives, expanding
+// blocks such as replace ( ... ) into one directive per line
+func goModDirectives(data []byte) [][]string {
+	var directives [][]string
+	block := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case block != "" && fields[0] == ")":
+			block = ""
+		case block != "":
+			directives = append(directives, append([]string{block}, fields...))
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+		default:
+			directives = append(directives, fields)
+		}
+	}
+
+	return directives
+}
+
+// addGoReplace records a replace directive if it points to a local directory
+func addGoReplace(replaces map[string]string, dir string, args []string) {
+	arrow := -1
+	for i, arg := range args {
+		if arg == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 || arrow+1 >= len(args) {
+		return
+	}
+
+	target := args[arrow+1]
+	if !strings.HasPrefix(target, "./") && !strings.HasPrefix(target, "../") && !filepath.IsAbs(target) {
+		return
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	replaces[args[0]] = target
+}
+
+// goModulePath returns the module path declared by the go.mod in a directory, or an empty string
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, directive := range goModDirectives(data) {
+		if directive[0] == "module" && len(directive) > 1 {
+			return strings.Trim(directive[1], `"`)
+		}
+	}
+	return ""
+}
+
+// findGoWork returns the nearest go.work file above a directory within the
+// chunking root, or an empty string
+func findGoWork(dir string, rootDir string) string {
+	for {
+		path := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || !inRootDir(parent, rootDir) {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// inRootDir checks if a directory is the chunking root or below it. Without
+// a root, directories are only looked at on their own
+func inRootDir(dir string, rootDir string) bool {
+	rel, err := filepath.Rel(rootDir, dir)
+	return rootDir != "" && err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// cutPathPrefix removes a path prefix that ends at a path element boundary
+func cutPathPrefix(path string, prefix string) (string, bool) {
+	if path == prefix {
+		return "", true
+	}
+	if rest, ok := strings.CutPrefix(path, prefix+"/"); ok {
+		return rest, true
+	}
+	return "", false
+}
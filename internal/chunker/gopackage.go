@@ -0,0 +1,152 @@
+package chunker
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// packageSummary creates the API summary chunk of a file's package, the
+// first time one of the package's files is chunked. It holds the package
+// doc and the signatures of the exported declarations of every file that
+// builds for the current platform and isn't left out of processing, in file
+// name order. It's located at the package doc and clause of the file holding
+// the package doc (or of the first file)
+func (c *GoChunker) packageSummary(filePath string, content []byte, file *ast.File, symbolTable *model.SymbolTable, options ChunkingOptions) (model.Chunk, bool) {
+	if strings.HasSuffix(filePath, "_test.go") {
+		return model.Chunk{}, false
+	}
+
+	dir := filepath.Dir(filePath)
+	key := dir + "|" + file.Name.Name
+	if c.summarized == nil {
+		c.summarized = make(map[string]bool)
+	}
+	if c.summarized[key] {
+		return model.Chunk{}, false
+	}
+	c.summarized[key] = true
+
+	fset := token.NewFileSet()
+	var paths []string
+	files := make(map[string]*ast.File)
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || path == filePath {
+			continue
+		}
+		if options.Ignored != nil && options.Ignored(path) {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+		otherContent, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		other, _ := parseGoFile(fset, path, otherContent)
+		if other == nil || other.Name.Name != file.Name.Name {
+			continue
+		}
+		if c.Generated == GeneratedSkip && ast.IsGenerated(other) {
+			continue
+		}
+		paths = append(paths, path)
+		files[path] = other
+	}
+
+	// The file being chunked is parsed again into the summary's file set
+	current, _ := parseGoFile(fset, filePath, content)
+	if current == nil {
+		return model.Chunk{}, false
+	}
+	paths = append(paths, filePath)
+	files[filePath] = current
+	sort.Strings(paths)
+
+	// doc.go holds the package doc by convention, otherwise the first file with one wins
+	docPath := paths[0]
+	var doc *ast.CommentGroup
+	for _, path := range paths {
+		if files[path].Doc != nil && (doc == nil || filepath.Base(path) == "doc.go") {
+			docPath, doc = path, files[path].Doc
+		}
+	}
+	start := files[docPath].Package
+	if doc != nil {
+		start = doc.Pos()
+	}
+
+	var summary bytes.Buffer
+	if doc != nil {
+		for _, comment := range doc.List {
+			summary.WriteString(comment.Text + "\n")
+		}
+	}
+	summary.WriteString("package " + file.Name.Name + "\n")
+
+	exported := 0
+	for _, path := range paths {
+		symbols, _ := writeExportedDecls(&summary, fset, files[path].Decls)
+		exported += len(symbols)
+	}
+	if exported == 0 && doc == nil {
+		return model.Chunk{}, false
+	}
+
+	pkgPath := c.packagePath(dir)
+	summaryContent := summary.String()
+	chunk := model.Chunk{
+		ID:         util.GenerateID(dir, summaryContent),
+		FilePath:   docPath,
+		Content:    summaryContent,
+		StartLine:  fset.Position(start).Line,
+		EndLine:    fset.Position(files[docPath].Name.End()).Line,
+		Language:   "go",
+		Symbols:    []string{pkgPath},
+		Kind:       "package",
+		TokenCount: util.EstimateTokenCount(summaryContent),
+	}
+
+	symbolTable.AddDefinition(pkgPath, model.SymbolDefinition{
+		Name:      pkgPath,
+		ChunkID:   chunk.ID,
+		FilePath:  docPath,
+		StartLine: chunk.StartLine,
+		EndLine:   chunk.EndLine,
+		Type:      "package",
+	})
+
+	return chunk, true
+}
+
+// addMemberReferences links the chunks of a file to the summary of its
+// package. External test packages aren't members
+func (c *GoChunker) addMemberReferences(filePath string, file *ast.File, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	if strings.HasSuffix(file.Name.Name, "_test") {
+		return
+	}
+
+	pkgPath := c.packagePath(filepath.Dir(filePath))
+	for _, chunk := range chunks {
+		symbolTable.AddReference(pkgPath, model.SymbolReference{
+			Name:     pkgPath,
+			ChunkID:  chunk.ID,
+			FilePath: filePath,
+			Line:     chunk.StartLine,
+			Kind:     "member",
+		})
+	}
+}
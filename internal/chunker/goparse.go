@@ -0,0 +1,191 @@
+package chunker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// goDeclPrefixes start the lines of top-level declarations in gofmt'd code
+var goDeclPrefixes = []string{"func ", "func(", "type ", "var ", "const ", "import "}
+
+// goBrokenRegion is a range of lines left out of the AST because of the
+// syntax error found in it
+type goBrokenRegion struct {
+	startLine int
+	endLine   int
+	err       scanner.Error
+}
+
+// parseGoFile parses a Go file, recovering from syntax errors. The parser
+// can't be trusted past an error (an unbalanced brace swallows the rest of
+// the file), so the top-level declaration holding the first error is blanked
+// out and the file parsed again, until it parses cleanly. Blanked lines keep
+// their newlines, so the positions of the other declarations don't change.
+// The file is nil if the error can't be isolated, e.g. in the package clause
+func parseGoFile(fset *token.FileSet, filePath string, content []byte) (*ast.File, []goBrokenRegion) {
+	lines := strings.Split(string(content), "\n")
+	src := content
+	var broken []goBrokenRegion
+
+	for {
+		file, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
+		if err == nil {
+			return file, broken
+		}
+
+		var errs scanner.ErrorList
+		if !errors.As(err, &errs) || len(errs) == 0 {
+			return nil, broken
+		}
+		first := *errs[0]
+
+		// The declaration the error is in may have started well before it
+		startLine, endLine := 0, 0
+		if file != nil {
+			for _, decl := range file.Decls {
+				declStart := fset.Position(decl.Pos()).Line
+				declEnd := fset.Position(decl.End()).Line
+				if declStart <= first.Pos.Line && (declEnd == 0 || declEnd >= first.Pos.Line) {
+					startLine, endLine = goDeclRegion(lines, declStart)
+				}
+			}
+		}
+		if startLine == 0 || isBroken(broken, startLine) {
+			startLine, endLine = goDeclRegion(lines, first.Pos.Line)
+		}
+		if startLine == 0 || isBroken(broken, startLine) {
+			// The whole file is broken, blamed on the first error found
+			if len(broken) > 0 {
+				first = broken[0].err
+			}
+			return nil, []goBrokenRegion{{startLine: 1, endLine: len(lines), err: first}}
+		}
+
+		broken = append(broken, goBrokenRegion{startLine: startLine, endLine: endLine, err: first})
+		src = blankLines(src, startLine, endLine)
+	}
+}
+
+// goDeclRegion returns the lines of the top-level declaration that contains
+// a line, found from the lines declarations start on, along with its doc
+// comment. It returns zeros if the line comes before the first declaration
+func goDeclRegion(lines []string, line int) (int, int) {
+	isDeclStart := func(i int) bool {
+		for _, prefix := range goDeclPrefixes {
+			if strings.HasPrefix(lines[i], prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	isComment := func(i int) bool {
+		return strings.HasPrefix(lines[i], "//")
+	}
+
+	line = min(line, len(lines))
+	start := line - 1
+	for start >= 0 && !isDeclStart(start) {
+		start--
+	}
+	if start < 0 {
+		return 0, 0
+	}
+	for start > 0 && isComment(start-1) {
+		start--
+	}
+
+	end := line
+	for end < len(lines) && !isDeclStart(end) {
+		end++
+	}
+	// Blank lines and the doc comment of the next declaration aren't part of this one
+	for end > line && (strings.TrimSpace(lines[end-1]) == "" || isComment(end-1)) {
+		end--
+	}
+
+	return start + 1, end
+}
+
+// isBroken reports whether a line is in one of the broken regions
+func isBroken(broken []goBrokenRegion, line int) bool {
+	for _, region := range broken {
+		if line >= region.startLine && line <= region.endLine {
+			return true
+		}
+	}
+	return false
+}
+
+// blankLines returns a copy of src with the given lines replaced by spaces
+func blankLines(src []byte, startLine, endLine int) []byte {
+	blanked := bytes.Clone(src)
+	line := 1
+	for i, b := range blanked {
+		if b == '\n' {
+			line++
+			continue
+		}
+		if line >= startLine && line <= endLine {
+			blanked[i] = ' '
+		}
+	}
+	return blanked
+}
+
+// brokenChunks creates line-based chunks for the regions of a file that
+// didn't parse, recording each syntax error as a diagnostic and as the
+// parse_error metadata of the region's chunks
+func (c *GoChunker) brokenChunks(filePath string, content []byte, broken []goBrokenRegion, symbolTable *model.SymbolTable, options ChunkingOptions) []model.Chunk {
+	lines := strings.Split(string(content), "\n")
+
+	var chunks []model.Chunk
+	for _, region := range broken {
+		symbolTable.AddDiagnostic(model.Diagnostic{
+			FilePath: filePath,
+			Line:     region.err.Pos.Line,
+			Column:   region.err.Pos.Column,
+			Message:  region.err.Msg,
+		})
+
+		message := fmt.Sprintf("%d:%d: %s", region.err.Pos.Line, region.err.Pos.Column, region.err.Msg)
+		for _, chunk := range goLineChunks(filePath, lines, region.startLine, region.endLine, options.MaxChunkSize) {
+			setMetadata(&chunk, "parse_error", message)
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// goLineChunks splits lines startLine to endLine into chunks of at most
+// maxSize lines
+func goLineChunks(filePath string, lines []string, startLine, endLine int, maxSize int) []model.Chunk {
+	if maxSize <= 0 {
+		maxSize = endLine - startLine + 1
+	}
+
+	var chunks []model.Chunk
+	for i := startLine - 1; i < endLine && i < len(lines); i += maxSize {
+		end := min(i+maxSize, endLine, len(lines))
+
+		chunkContent := strings.Join(lines[i:end], "\n")
+		chunks = append(chunks, model.Chunk{
+			ID:         util.GenerateID(filePath, chunkContent),
+			FilePath:   filePath,
+			StartLine:  i + 1,
+			EndLine:    end,
+			Content:    chunkContent,
+			Language:   "go",
+			TokenCount: util.EstimateTokenCount(chunkContent),
+		})
+	}
+	return chunks
+}
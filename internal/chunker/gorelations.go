@@ -0,0 +1,192 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// addTypeRelations records the types embedded in the structs and interfaces
+// of a declaration, and the constraints of their type parameters
+func (c *GoChunker) addTypeRelations(fset *token.FileSet, file *ast.File, decl *ast.GenDecl, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	for _, spec := range decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		c.addConstraintRelations(fset, file, typeSpec.TypeParams, typeSpec.Name.Name, chunk, symbolTable)
+
+		var fields *ast.FieldList
+		switch t := typeSpec.Type.(type) {
+		case *ast.StructType:
+			fields = t.Fields
+		case *ast.InterfaceType:
+			fields = t.Methods
+		}
+		if fields == nil {
+			continue
+		}
+
+		for _, field := range fields.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+			// Type set elements such as ~int | ~string constrain rather than embed
+			kind := "embeds"
+			switch field.Type.(type) {
+			case *ast.BinaryExpr, *ast.UnaryExpr:
+				kind = "constraint"
+			}
+			c.addTypeRelation(fset, file, field.Type, kind, typeSpec.Name.Name, chunk, symbolTable)
+		}
+	}
+}
+
+// addConstraintRelations records the types used in type parameter
+// constraints, as relations of the chunk's symbol named from
+func (c *GoChunker) addConstraintRelations(fset *token.FileSet, file *ast.File, params *ast.FieldList, from string, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	if params == nil {
+		return
+	}
+	for _, param := range params.List {
+		c.addTypeRelation(fset, file, param.Type, "constraint", from, chunk, symbolTable)
+	}
+}
+
+// addTypeRelation records a reference of the given kind from the chunk's
+// symbol named from to each named type in a type expression
+func (c *GoChunker) addTypeRelation(fset *token.FileSet, file *ast.File, expr ast.Expr, kind string, from string, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		c.addTypeRelation(fset, file, e.X, kind, from, chunk, symbolTable)
+		c.addTypeRelation(fset, file, e.Y, kind, from, chunk, symbolTable)
+	case *ast.UnaryExpr:
+		c.addTypeRelation(fset, file, e.X, kind, from, chunk, symbolTable)
+	case *ast.StarExpr:
+		c.addTypeRelation(fset, file, e.X, kind, from, chunk, symbolTable)
+	case *ast.IndexExpr:
+		c.addTypeRelation(fset, file, e.X, kind, from, chunk, symbolTable)
+	case *ast.IndexListExpr:
+		c.addTypeRelation(fset, file, e.X, kind, from, chunk, symbolTable)
+	case *ast.InterfaceType:
+		// Inline constraints such as interface{ ~int; Stringer }
+		for _, field := range e.Methods.List {
+			if len(field.Names) == 0 {
+				c.addTypeRelation(fset, file, field.Type, "constraint", from, chunk, symbolTable)
+			}
+		}
+	case *ast.Ident:
+		if isBasicType(e.Name) || e.Name == "any" || e.Name == "comparable" {
+			return
+		}
+		c.addRelation(symbolTable, chunk, from, e.Name, c.packagePath(filepath.Dir(chunk.FilePath)), kind, fset.Position(e.Pos()).Line)
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return
+		}
+		if path := importPathOf(file, x.Name); path != "" {
+			path = c.canonicalImport(filepath.Dir(chunk.FilePath), path)
+			c.addRelation(symbolTable, chunk, from, e.Sel.Name, path, kind, fset.Position(e.Sel.Pos()).Line)
+		}
+	}
+}
+
+// addRelation records a structural reference from the chunk's symbol named
+// from to a symbol in the given package
+func (c *GoChunker) addRelation(symbolTable *model.SymbolTable, chunk model.Chunk, from string, name string, pkg string, kind string, line int) {
+	symbolTable.AddReference(name, model.SymbolReference{
+		Name:     name,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+		Kind:     kind,
+		Package:  pkg,
+		Symbol:   from,
+	})
+}
+
+// interfaceMethodNames returns the methods an interface declares directly,
+// counting an embedded error as its Error method
+func interfaceMethodNames(iface *ast.InterfaceType) []string {
+	var methods []string
+	for _, field := range iface.Methods.List {
+		if _, ok := field.Type.(*ast.FuncType); ok {
+			for _, name := range field.Names {
+				methods = append(methods, name.Name)
+			}
+		} else if ident, ok := field.Type.(*ast.Ident); ok && ident.Name == "error" {
+			methods = append(methods, "Error")
+		}
+	}
+	return methods
+}
+
+// collectTypedImplements records an "implements" reference from each type
+// declared in a file to each interface of its package that the type or a
+// pointer to it satisfies
+func (c *GoChunker) collectTypedImplements(typed *goPackage, filePath string, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	if typed.pkg == nil {
+		return
+	}
+
+	var interfaces []*types.TypeName
+	scope := typed.pkg.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		if iface, ok := named.Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+			interfaces = append(interfaces, obj)
+		}
+	}
+
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() || c.typeFset.Position(obj.Pos()).Filename != filePath {
+			continue
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+			continue
+		}
+
+		line := c.typeFset.Position(obj.Pos()).Line
+		var chunk model.Chunk
+		for _, candidate := range chunks {
+			if line >= candidate.StartLine && line <= candidate.EndLine {
+				chunk = candidate
+				break
+			}
+		}
+		if chunk.ID == "" {
+			continue
+		}
+
+		for _, ifaceObj := range interfaces {
+			iface := ifaceObj.Type().Underlying().(*types.Interface)
+			if !types.Implements(named, iface) && !types.Implements(types.NewPointer(named), iface) {
+				continue
+			}
+			defPos := c.typeFset.Position(ifaceObj.Pos())
+			symbolTable.AddReference(ifaceObj.Name(), model.SymbolReference{
+				Name:           ifaceObj.Name(),
+				ChunkID:        chunk.ID,
+				FilePath:       filePath,
+				Line:           line,
+				Kind:           "implements",
+				DefinitionFile: defPos.Filename,
+				DefinitionLine: defPos.Line,
+				Symbol:         obj.Name(),
+			})
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// goConfigTags are the struct tags naming the configuration key a field is
+// decoded from
+var goConfigTags = []string{"json", "yaml", "mapstructure", "toml"}
+
+// goEnvTag is the struct tag naming the environment variable a field is read from
+const goEnvTag = "env"
+
+// addStructSchema records the configuration schema of the struct types of a
+// declaration. Fields tagged with a configuration key define a config:path
+// symbol, with the path following the tagged fields of the file's structs
+// that hold the type, and fields tagged with an environment variable define
+// an env:NAME symbol. The tagged fields are listed in the schema metadata
+func (c *GoChunker) addStructSchema(file *ast.File, decl *ast.GenDecl, chunk *model.Chunk, symbolTable *model.SymbolTable) {
+	var schema []string
+	define := func(symbol string, fieldType string) {
+		symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+			Name:      symbol,
+			ChunkID:   chunk.ID,
+			FilePath:  chunk.FilePath,
+			StartLine: chunk.StartLine,
+			EndLine:   chunk.EndLine,
+			Type:      fieldType,
+		})
+	}
+
+	var walk func(name string, structType *ast.StructType, prefixes []string)
+	walk = func(name string, structType *ast.StructType, prefixes []string) {
+		for _, field := range structType.Fields.List {
+			if field.Tag == nil || len(field.Names) == 0 {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+
+			keys := structTagKeys(reflect.StructTag(tag))
+			var paths []string
+			for _, prefix := range prefixes {
+				for _, key := range keys {
+					paths = append(paths, prefix+key)
+				}
+			}
+			for _, path := range paths {
+				define(configKeySymbol(path), "config")
+			}
+			env, _, _ := strings.Cut(reflect.StructTag(tag).Get(goEnvTag), ",")
+			if env != "" {
+				define(envSymbol(env), "env")
+			}
+			if len(keys) > 0 || env != "" {
+				schema = append(schema, name+"."+field.Names[0].Name+" "+types.ExprString(field.Type)+" "+tag)
+			}
+
+			// Anonymous structs nest their keys under the field's
+			if inner, ok := derefType(field.Type).(*ast.StructType); ok && len(paths) > 0 {
+				nested := make([]string, len(paths))
+				for i, path := range paths {
+					nested[i] = path + "."
+				}
+				walk(name+"."+field.Names[0].Name, inner, nested)
+			}
+		}
+	}
+
+	for _, spec := range decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+			walk(typeSpec.Name.Name, structType, configPrefixes(file, typeSpec.Name.Name, 0))
+		}
+	}
+
+	if len(schema) > 0 {
+		setMetadata(chunk, "schema", strings.Join(schema, "\n"))
+	}
+}
+
+// configPrefixes returns the key paths under which a struct type is decoded,
+// each ending with a dot, from the tagged fields of the file's structs
+// holding it. A type no struct holds is decoded at the root
+func configPrefixes(file *ast.File, typeName string, depth int) []string {
+	var prefixes []string
+	if depth < 5 {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok || typeSpec.Name.Name == typeName {
+					continue
+				}
+				for _, field := range structType.Fields.List {
+					ident, ok := derefType(field.Type).(*ast.Ident)
+					if !ok || ident.Name != typeName || field.Tag == nil {
+						continue
+					}
+					tag, err := strconv.Unquote(field.Tag.Value)
+					if err != nil {
+						continue
+					}
+					for _, parent := range configPrefixes(file, typeSpec.Name.Name, depth+1) {
+						for _, key := range structTagKeys(reflect.StructTag(tag)) {
+							if prefix := parent + key + "."; !slices.Contains(prefixes, prefix) {
+								prefixes = append(prefixes, prefix)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(prefixes) == 0 {
+		return []string{""}
+	}
+	return prefixes
+}
+
+// structTagKeys returns the distinct configuration keys a struct tag names,
+// lowercased since they're matched case-insensitively
+func structTagKeys(tag reflect.StructTag) []string {
+	var keys []string
+	for _, name := range goConfigTags {
+		key, _, _ := strings.Cut(tag.Get(name), ",")
+		key = strings.ToLower(key)
+		if key != "" && key != "-" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// derefType returns the element type of pointers, slices and arrays
+func derefType(expr ast.Expr) ast.Expr {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ArrayType:
+			expr = e.Elt
+		default:
+			return expr
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// goTestKinds maps the prefixes go test recognizes to chunk kinds
+var goTestKinds = []struct{ prefix, kind string }{
+	{"Test", "test"},
+	{"Benchmark", "benchmark"},
+	{"Example", "example"},
+	{"Fuzz", "fuzz"},
+}
+
+// goTestTableSuffixes are stripped from table variable names to find the symbol they test
+var goTestTableSuffixes = []string{"TestCases", "Cases", "Tests", "Table"}
+
+// goTestFunc returns the kind of a test function and the name following its
+// prefix, or an empty kind if the declaration isn't one
+func goTestFunc(filePath string, decl *ast.FuncDecl) (string, string) {
+	if !strings.HasSuffix(filePath, "_test.go") || decl.Recv != nil {
+		return "", ""
+	}
+
+	for _, test := range goTestKinds {
+		rest, ok := strings.CutPrefix(decl.Name.Name, test.prefix)
+		if !ok {
+			continue
+		}
+		// go test ignores names such as Testify, whose prefix isn't a word
+		if rest != "" && unicode.IsLower(rune(rest[0])) {
+			return "", ""
+		}
+		return test.kind, rest
+	}
+	return "", ""
+}
+
+// goTestTargets returns the symbols a test name refers to by convention:
+// TestFoo and ExampleFoo_suffix test Foo, ExampleT_M tests T.M, and both the
+// exported and unexported spellings are tried since TestParse may test parse
+func goTestTargets(name string) []string {
+	name = strings.TrimPrefix(name, "_")
+	if name == "" || name == "Main" {
+		return nil
+	}
+
+	parts := strings.Split(name, "_")
+	bases := []string{parts[0]}
+	if lower := string(unicode.ToLower(rune(parts[0][0]))) + parts[0][1:]; lower != parts[0] {
+		bases = append(bases, lower)
+	}
+
+	var targets []string
+	for _, base := range bases {
+		targets = append(targets, base)
+		// A lowercase suffix names a case or an example variant rather than a method
+		if len(parts) > 1 && parts[1] != "" && unicode.IsUpper(rune(parts[1][0])) {
+			targets = append(targets, base+"."+parts[1])
+		}
+	}
+	return targets
+}
+
+// goTestTable returns an expression as a table of test cases if it's a
+// literal slice or map of anonymous structs
+func goTestTable(expr ast.Expr) (*ast.CompositeLit, bool) {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+
+	var elem ast.Expr
+	switch t := lit.Type.(type) {
+	case *ast.ArrayType:
+		elem = t.Elt
+	case *ast.MapType:
+		elem = t.Value
+	}
+	if star, ok := elem.(*ast.StarExpr); ok {
+		elem = star.X
+	}
+	if _, ok := elem.(*ast.StructType); !ok {
+		return nil, false
+	}
+	return lit, true
+}
+
+// addTestReferences marks the chunks of a test, benchmark, example or fuzz
+// target with its kind and links them to what it exercises: the symbol its
+// name follows by convention, the functions its body calls and the functions
+// named in its table of cases
+func (c *GoChunker) addTestReferences(fset *token.FileSet, file *ast.File, decl *ast.FuncDecl, kind string, name string, chunks []model.Chunk, resolveCalls bool, symbolTable *model.SymbolTable) {
+	for i := range chunks {
+		chunks[i].Kind = kind
+	}
+
+	dir := c.packagePath(filepath.Dir(chunks[0].FilePath))
+	for _, target := range goTestTargets(name) {
+		c.addQualifiedReference(symbolTable, chunks[0], target, dir, chunks[0].StartLine)
+	}
+
+	if decl.Body == nil {
+		return
+	}
+
+	chunkAt := func(pos token.Pos) model.Chunk {
+		line := fset.Position(pos).Line
+		for _, chunk := range chunks {
+			if line >= chunk.StartLine && line <= chunk.EndLine {
+				return chunk
+			}
+		}
+		return chunks[0]
+	}
+
+	cases := 0
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.CompositeLit:
+			if lit, ok := goTestTable(n); ok {
+				cases += len(lit.Elts)
+				if resolveCalls {
+					c.addTableReferences(fset, decl, lit, chunkAt(lit.Pos()), symbolTable)
+				}
+			}
+		case *ast.CallExpr:
+			if resolveCalls {
+				c.addCallReference(fset, file, decl, n, chunkAt(n.Pos()), symbolTable)
+			}
+		}
+		return true
+	})
+
+	if cases > 0 {
+		setMetadata(&chunks[0], "test_cases", strconv.Itoa(cases))
+	}
+}
+
+// addTestTableReferences marks a package-level table of test cases and links
+// it to the symbol its name follows, e.g. parseTests to parse
+func (c *GoChunker) addTestTableReferences(fset *token.FileSet, decl *ast.GenDecl, chunk *model.Chunk, symbolTable *model.SymbolTable) {
+	cases := 0
+	for _, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for i, value := range valueSpec.Values {
+			lit, ok := goTestTable(value)
+			if !ok || i >= len(valueSpec.Names) {
+				continue
+			}
+			cases += len(lit.Elts)
+
+			name := valueSpec.Names[i].Name
+			for _, suffix := range goTestTableSuffixes {
+				if trimmed, ok := strings.CutSuffix(name, suffix); ok && trimmed != "" {
+					name = trimmed
+					break
+				}
+			}
+			targets := []string{name}
+			if upper := string(unicode.ToUpper(rune(name[0]))) + name[1:]; upper != name {
+				targets = append(targets, upper)
+			}
+			for _, target := range targets {
+				c.addQualifiedReference(symbolTable, *chunk, target, c.packagePath(filepath.Dir(chunk.FilePath)), fset.Position(valueSpec.Pos()).Line)
+			}
+		}
+	}
+
+	if cases > 0 {
+		chunk.Kind = "test_table"
+		setMetadata(chunk, "test_cases", strconv.Itoa(cases))
+	}
+}
+
+// addCallReference links a test to a function it calls, either in its own
+// package or through an import outside the standard library
+func (c *GoChunker) addCallReference(fset *token.FileSet, file *ast.File, decl *ast.FuncDecl, call *ast.CallExpr, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	fun := call.Fun
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	line := fset.Position(call.Pos()).Line
+	switch f := fun.(type) {
+	case *ast.Ident:
+		if !isLocalIdent(decl, f) && !isBasicType(f.Name) && !isKeyword(f.Name) {
+			c.addQualifiedReference(symbolTable, chunk, f.Name, c.packagePath(filepath.Dir(chunk.FilePath)), line)
+		}
+	case *ast.SelectorExpr:
+		x, ok := f.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return
+		}
+		if path := importPathOf(file, x.Name); path != "" && !isStdImport(path) {
+			path = c.canonicalImport(filepath.Dir(chunk.FilePath), path)
+			c.addQualifiedReference(symbolTable, chunk, f.Sel.Name, path, line)
+		}
+	}
+}
+
+// addTableReferences links a test to the package-level names used as values
+// in its cases, such as the function each case runs
+func (c *GoChunker) addTableReferences(fset *token.FileSet, decl *ast.FuncDecl, lit *ast.CompositeLit, chunk model.Chunk, symbolTable *model.SymbolTable) {
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		fields, ok := elt.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		for _, field := range fields.Elts {
+			if kv, ok := field.(*ast.KeyValueExpr); ok {
+				field = kv.Value
+			}
+			ident, ok := field.(*ast.Ident)
+			if !ok || isLocalIdent(decl, ident) || isBasicType(ident.Name) || isKeyword(ident.Name) {
+				continue
+			}
+			c.addQualifiedReference(symbolTable, chunk, ident.Name, c.packagePath(filepath.Dir(chunk.FilePath)), fset.Position(ident.Pos()).Line)
+		}
+	}
+}
+
+// addQualifiedReference records a reference that only matches definitions in the given package
+func (c *GoChunker) addQualifiedReference(symbolTable *model.SymbolTable, chunk model.Chunk, name string, pkg string, line int) {
+	symbolTable.AddReference(name, model.SymbolReference{
+		Name:     name,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+		Package:  pkg,
+	})
+}
+
+// isLocalIdent checks if an identifier was declared within a declaration,
+// such as a parameter or a closure assigned to a variable
+func isLocalIdent(decl ast.Decl, ident *ast.Ident) bool {
+	if ident.Obj == nil {
+		return false
+	}
+	pos := ident.Obj.Pos()
+	return pos.IsValid() && pos >= decl.Pos() && pos < decl.End()
+}
+
+// importPathOf returns the path of the import a file refers to by name, or an empty string
+func importPathOf(file *ast.File, name string) string {
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		importName := goImportName(path)
+		if imp.Name != nil {
+			importName = imp.Name.Name
+		}
+		if importName == name {
+			return path
+		}
+	}
+	return ""
+}
+
+// goImportName returns the name a package is assumed to declare from its
+// import path, as goimports does: a major version element such as /v2 is
+// skipped, and a go- prefix and anything past the identifier, such as the
+// .v3 of gopkg.in/yaml.v3, are dropped
+func goImportName(importPath string) string {
+	base := path.Base(importPath)
+	if version, ok := strings.CutPrefix(base, "v"); ok {
+		if _, err := strconv.Atoi(version); err == nil && path.Dir(importPath) != "." {
+			base = path.Base(path.Dir(importPath))
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// setMetadata sets a metadata entry on a chunk
+func setMetadata(chunk *model.Chunk, key string, value string) {
+	if chunk.Metadata == nil {
+		chunk.Metadata = make(map[string]string)
+	}
+	chunk.Metadata[key] = value
+}
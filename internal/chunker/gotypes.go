@@ -0,0 +1,267 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// goPackage is a type-checked package, shared by the chunks of all its files
+type goPackage struct {
+	pkg   *types.Package
+	files map[string]*ast.File // by file path
+	info  *types.Info
+}
+
+// goImporter resolves standard library imports from source. Other imports
+// become empty packages, so that the package still type-checks and selectors
+// into them can be recorded by import path
+type goImporter struct {
+	std  types.Importer
+	fake map[string]*types.Package
+}
+
+// Import implements the types.Importer interface
+func (imp *goImporter) Import(path string) (*types.Package, error) {
+	if isStdImport(path) {
+		if pkg, err := imp.std.Import(path); err == nil {
+			return pkg, nil
+		}
+	}
+
+	if pkg, ok := imp.fake[path]; ok {
+		return pkg, nil
+	}
+	pkg := types.NewPackage(path, goImportName(path))
+	pkg.MarkComplete()
+	imp.fake[path] = pkg
+	return pkg, nil
+}
+
+// nameImports gives a file's unnamed imports the names type checking found
+// for their packages where they differ from the names assumed from their
+// paths, so that selectors are matched to imports by the package's real name
+func (p *goPackage) nameImports(filePath string, file *ast.File) {
+	typedFile, ok := p.files[filePath]
+	if !ok {
+		return
+	}
+	for _, typedImp := range typedFile.Imports {
+		pkgName, ok := p.info.Implicits[typedImp].(*types.PkgName)
+		if !ok || typedImp.Name != nil {
+			continue
+		}
+		path, err := strconv.Unquote(typedImp.Path.Value)
+		if err != nil || pkgName.Imported().Name() == goImportName(path) {
+			continue
+		}
+		for _, imp := range file.Imports {
+			if imp.Name == nil && imp.Path.Value == typedImp.Path.Value {
+				imp.Name = &ast.Ident{NamePos: imp.Path.Pos(), Name: pkgName.Imported().Name()}
+			}
+		}
+	}
+}
+
+// typeCheckedPackage returns the type-checked package containing a file,
+// checking it on first use. It returns nil if the file can't be checked
+func (c *GoChunker) typeCheckedPackage(filePath string, content []byte) *goPackage {
+	if c.typeFset == nil {
+		c.typeFset = token.NewFileSet()
+		c.importer = &goImporter{
+			std:  importer.ForCompiler(c.typeFset, "source", nil),
+			fake: make(map[string]*types.Package),
+		}
+		c.packages = make(map[string]*goPackage)
+	}
+
+	clause, err := parser.ParseFile(token.NewFileSet(), filePath, content, parser.PackageClauseOnly)
+	if err != nil {
+		return nil
+	}
+
+	// Files excluded by build constraints when their package was checked fall back to name matching
+	dir := filepath.Dir(filePath)
+	key := dir + "|" + clause.Name.Name
+	if pkg, ok := c.packages[key]; ok {
+		if _, ok := pkg.files[filePath]; ok {
+			return pkg
+		}
+		return nil
+	}
+
+	file, err := parser.ParseFile(c.typeFset, filePath, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	// The package is made of the files in the directory with the same package
+	// clause that build for the current platform, plus the file being chunked
+	files := map[string]*ast.File{filePath: file}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || path == filePath {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+		other, err := parser.ParseFile(c.typeFset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil || other.Name.Name != file.Name.Name {
+			continue
+		}
+		files[path] = other
+	}
+
+	astFiles := make([]*ast.File, 0, len(files))
+	for _, f := range files {
+		astFiles = append(astFiles, f)
+	}
+
+	info := &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Implicits:  make(map[ast.Node]types.Object),
+	}
+	config := types.Config{
+		Importer: c.importer,
+		// Keep going past errors such as selectors into packages we didn't import
+		Error: func(err error) {},
+	}
+	pkg, _ := config.Check(file.Name.Name, c.typeFset, astFiles, info)
+
+	typed := &goPackage{pkg: pkg, files: files, info: info}
+	c.packages[key] = typed
+	return typed
+}
+
+// collectTypedReferences records a reference for every identifier that type
+// checking resolved to a declaration in another top-level declaration of the
+// package, or to a name in an imported package outside the standard library
+func (c *GoChunker) collectTypedReferences(typed *goPackage, filePath string, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	file := typed.files[filePath]
+
+	chunkAt := func(line int) string {
+		for _, chunk := range chunks {
+			if line >= chunk.StartLine && line <= chunk.EndLine {
+				return chunk.ID
+			}
+		}
+		return ""
+	}
+
+	for _, decl := range file.Decls {
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.SelectorExpr:
+				// pkg.Name where pkg wasn't type-checked
+				x, ok := n.X.(*ast.Ident)
+				if !ok || typed.info.Uses[n.Sel] != nil {
+					return true
+				}
+				pkgName, ok := typed.info.Uses[x].(*types.PkgName)
+				if !ok || isStdImport(pkgName.Imported().Path()) {
+					return true
+				}
+
+				line := c.typeFset.Position(n.Sel.Pos()).Line
+				if chunkID := chunkAt(line); chunkID != "" {
+					symbolTable.AddReference(n.Sel.Name, model.SymbolReference{
+						Name:     n.Sel.Name,
+						ChunkID:  chunkID,
+						FilePath: filePath,
+						Line:     line,
+						Package:  c.canonicalImport(filepath.Dir(filePath), pkgName.Imported().Path()),
+					})
+				}
+
+			case *ast.Ident:
+				obj := typed.info.Uses[n]
+				if obj == nil || obj.Pkg() != typed.pkg || !obj.Pos().IsValid() {
+					return true
+				}
+
+				// Declarations within the same top-level declaration are local
+				if obj.Pos() >= decl.Pos() && obj.Pos() < decl.End() {
+					return true
+				}
+
+				name := typed.declaredSymbol(obj.Pos())
+				defPos := c.typeFset.Position(obj.Pos())
+				line := c.typeFset.Position(n.Pos()).Line
+				chunkID := chunkAt(line)
+				if name == "" || chunkID == "" {
+					return true
+				}
+
+				symbolTable.AddReference(name, model.SymbolReference{
+					Name:           name,
+					ChunkID:        chunkID,
+					FilePath:       filePath,
+					Line:           line,
+					DefinitionFile: defPos.Filename,
+					DefinitionLine: defPos.Line,
+				})
+			}
+			return true
+		})
+	}
+}
+
+// declaredSymbol returns the chunk symbol of the top-level declaration
+// containing a position, e.g. "Type" for one of its fields
+func (p *goPackage) declaredSymbol(pos token.Pos) string {
+	for _, file := range p.files {
+		if pos < file.Pos() || pos >= file.End() {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			if pos < decl.Pos() || pos >= decl.End() {
+				continue
+			}
+
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				return funcSymbol(d)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if pos < spec.Pos() || pos >= spec.End() {
+						continue
+					}
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						return s.Name.Name
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							if name.Pos() == pos {
+								return name.Name
+							}
+						}
+						return s.Names[0].Name
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// isStdImport checks if an import path belongs to the standard library,
+// whose first path element never contains a dot
+func isStdImport(path string) bool {
+	first := strings.SplitN(path, "/", 2)[0]
+	return path != "C" && !strings.Contains(first, ".")
+}
@@ -0,0 +1,268 @@
+package chunker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// NotebookChunker implements the Chunker interface for Jupyter notebooks
+type NotebookChunker struct{}
+
+// NewNotebookChunker creates a new Jupyter notebook chunker
+func NewNotebookChunker() *NotebookChunker {
+	return &NotebookChunker{}
+}
+
+// notebookCell holds the parts of a notebook cell we care about.
+// Outputs are deliberately not decoded so they never reach a chunk.
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"`
+}
+
+// notebookMetadata holds the kernel information of a notebook
+type notebookMetadata struct {
+	Kernelspec struct {
+		Language string `json:"language"`
+	} `json:"kernelspec"`
+	LanguageInfo struct {
+		Name string `json:"name"`
+	} `json:"language_info"`
+}
+
+// parsedCell is a notebook cell with its position in the raw file
+type parsedCell struct {
+	index     int
+	cellType  string
+	source    string
+	startLine int
+	endLine   int
+}
+
+// Language returns the language this chunker supports
+func (c *NotebookChunker) Language() string {
+	return "jupyter"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *NotebookChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "jupyter"
+}
+
+// Chunk splits a notebook into chunks of cells, dropping all cell outputs
+func (c *NotebookChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	cells, metadata, err := c.parseNotebook(content)
+	if err != nil {
+		// A notebook we can't decode is still better indexed as text than not at all
+		return NewGenericChunker().Chunk(filePath, content, symbolTable, options)
+	}
+
+	kernelLanguage := strings.ToLower(metadata.Kernelspec.Language)
+	if kernelLanguage == "" {
+		kernelLanguage = strings.ToLower(metadata.LanguageInfo.Name)
+	}
+	if kernelLanguage == "" {
+		kernelLanguage = "python"
+	}
+
+	var chunks []model.Chunk
+	var group []parsedCell
+	groupLines := 0
+
+	flush := func() {
+		if len(group) > 0 {
+			chunks = append(chunks, c.createChunk(filePath, group, kernelLanguage, symbolTable))
+			group = nil
+			groupLines = 0
+		}
+	}
+
+	for _, cell := range cells {
+		if strings.TrimSpace(cell.source) == "" {
+			continue
+		}
+		lines := strings.Count(cell.source, "\n") + 1
+
+		// Only merge consecutive cells of the same type, and only while the
+		// group is still small and stays within the size budget
+		if len(group) > 0 && (group[0].cellType != cell.cellType ||
+			groupLines >= options.MinChunkSize ||
+			groupLines+lines > options.MaxChunkSize) {
+			flush()
+		}
+
+		group = append(group, cell)
+		groupLines += lines
+	}
+	flush()
+
+	return chunks, nil
+}
+
+// parseNotebook decodes the notebook, recording the line span of each cell in the raw JSON
+func (c *NotebookChunker) parseNotebook(content []byte) ([]parsedCell, notebookMetadata, error) {
+	var cells []parsedCell
+	var metadata notebookMetadata
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, metadata, fmt.Errorf("notebook is not a JSON object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, metadata, err
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "cells":
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				return nil, metadata, fmt.Errorf("notebook cells are not an array")
+			}
+			for dec.More() {
+				start := skipJSONSeparators(content, int(dec.InputOffset()))
+				var cell notebookCell
+				if err := dec.Decode(&cell); err != nil {
+					return nil, metadata, err
+				}
+				end := int(dec.InputOffset())
+
+				cells = append(cells, parsedCell{
+					index:     len(cells),
+					cellType:  cell.CellType,
+					source:    decodeCellSource(cell.Source),
+					startLine: bytes.Count(content[:start], []byte("\n")) + 1,
+					endLine:   bytes.Count(content[:end], []byte("\n")) + 1,
+				})
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, metadata, err
+			}
+
+		case "metadata":
+			if err := dec.Decode(&metadata); err != nil {
+				return nil, metadata, err
+			}
+
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, metadata, err
+			}
+		}
+	}
+
+	return cells, metadata, nil
+}
+
+// createChunk creates a chunk from a group of consecutive cells
+func (c *NotebookChunker) createChunk(filePath string, cells []parsedCell, kernelLanguage string, symbolTable *model.SymbolTable) model.Chunk {
+	sources := make([]string, len(cells))
+	var symbols []string
+	var functions []string
+
+	for i, cell := range cells {
+		sources[i] = strings.TrimRight(cell.source, "\n")
+		symbols = append(symbols, fmt.Sprintf("cell:%d", cell.index))
+		if cell.cellType == "code" {
+			functions = append(functions, extractNotebookFunctions(cell.source, kernelLanguage)...)
+		}
+	}
+	symbols = append(symbols, functions...)
+
+	content := strings.Join(sources, "\n\n")
+	chunkID := util.GenerateID(filePath, content)
+	startLine := cells[0].startLine
+	endLine := cells[len(cells)-1].endLine
+
+	language := kernelLanguage
+	switch cells[0].cellType {
+	case "markdown":
+		language = "markdown"
+	case "raw":
+		language = "text"
+	}
+
+	chunk := model.Chunk{
+		ID:         chunkID,
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   language,
+		Symbols:    symbols,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+
+	// Only the functions are real definitions; cell indexes are local to the notebook
+	for _, function := range functions {
+		symbolTable.AddDefinition(function, model.SymbolDefinition{
+			Name:      function,
+			ChunkID:   chunkID,
+			FilePath:  filePath,
+			StartLine: startLine,
+			EndLine:   endLine,
+			Type:      "function",
+		})
+	}
+
+	return chunk
+}
+
+// decodeCellSource decodes a cell source, which nbformat allows to be a string or a list of lines
+func decodeCellSource(raw json.RawMessage) string {
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		return strings.Join(lines, "")
+	}
+
+	var source string
+	if err := json.Unmarshal(raw, &source); err == nil {
+		return source
+	}
+
+	return ""
+}
+
+// skipJSONSeparators advances past whitespace and commas between array elements
+func skipJSONSeparators(content []byte, offset int) int {
+	for offset < len(content) {
+		switch content[offset] {
+		case ' ', '\t', '\r', '\n', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// notebookFunctionPatterns match function definitions for common notebook kernels
+var notebookFunctionPatterns = map[string]*regexp.Regexp{
+	"python": regexp.MustCompile(`(?m)^\s*(?:async\s+)?def\s+(\w+)\s*\(`),
+	"r":      regexp.MustCompile(`(?m)^\s*([\w.]+)\s*(?:<-|=)\s*function\s*\(`),
+	"julia":  regexp.MustCompile(`(?m)^\s*function\s+([\w!.]+)`),
+	"scala":  regexp.MustCompile(`(?m)^\s*def\s+(\w+)`),
+}
+
+// extractNotebookFunctions extracts the names of functions defined in a code cell
+func extractNotebookFunctions(source string, language string) []string {
+	pattern, ok := notebookFunctionPatterns[language]
+	if !ok {
+		pattern = notebookFunctionPatterns["python"]
+	}
+
+	var functions []string
+	for _, match := range pattern.FindAllStringSubmatch(source, -1) {
+		functions = append(functions, match[1])
+	}
+	return functions
+}
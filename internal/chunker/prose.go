@@ -0,0 +1,342 @@
+package chunker
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// defaultMaxChunkTokens is the prose chunk budget when none is configured
+const defaultMaxChunkTokens = 512
+
+// ProseChunker implements the Chunker interface for reStructuredText, AsciiDoc and plain text documents
+type ProseChunker struct{}
+
+// NewProseChunker creates a new prose document chunker
+func NewProseChunker() *ProseChunker {
+	return &ProseChunker{}
+}
+
+// proseUnit is a heading, paragraph or sentence run, addressed by byte offsets into the document
+type proseUnit struct {
+	start   int
+	end     int
+	heading bool
+	level   int
+	title   string
+}
+
+// Markup patterns
+var (
+	asciidocHeadingPattern   = regexp.MustCompile(`^(={1,6})\s+(\S.*)$`)
+	asciidocDelimiterPattern = regexp.MustCompile(`^(-{4,}|\.{4,}|={4,}|\*{4,}|_{4,}|\+{4,}|/{4,}|\|===)$`)
+	sentenceEndPattern       = regexp.MustCompile(`[.!?]+["')\]]*\s+`)
+)
+
+// Language returns the language this chunker supports
+func (c *ProseChunker) Language() string {
+	return "prose"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *ProseChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "rst" || language == "asciidoc" || language == "text"
+}
+
+// Chunk splits a document at its section headings and packs paragraphs, or
+// sentences of oversized paragraphs, into chunks of at most MaxChunkTokens
+func (c *ProseChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	text := string(content)
+	language := proseLanguage(filePath)
+	budget := options.MaxChunkTokens
+	if budget <= 0 {
+		budget = defaultMaxChunkTokens
+	}
+
+	var units []proseUnit
+	for _, unit := range parseProseUnits(text, language) {
+		if !unit.heading && util.EstimateTokenCount(text[unit.start:unit.end]) > budget {
+			units = append(units, splitSentences(text, unit, budget)...)
+			continue
+		}
+		units = append(units, unit)
+	}
+
+	var chunks []model.Chunk
+	var current []proseUnit
+	var breadcrumbs []string
+	currentCrumb := ""
+	currentTokens := 0
+
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, c.createChunk(filePath, text, language, current, currentCrumb, symbolTable))
+			current = nil
+			currentTokens = 0
+		}
+	}
+
+	for _, unit := range units {
+		tokens := util.EstimateTokenCount(text[unit.start:unit.end])
+
+		if unit.heading {
+			flush()
+			if unit.level < len(breadcrumbs) {
+				breadcrumbs = breadcrumbs[:unit.level]
+			}
+			breadcrumbs = append(breadcrumbs, unit.title)
+			currentCrumb = strings.Join(breadcrumbs, " > ")
+		} else if len(current) > 0 && currentTokens+tokens > budget && !onlyHeadings(current) {
+			// A heading always stays with the first paragraph of its section
+			flush()
+		}
+
+		current = append(current, unit)
+		currentTokens += tokens
+	}
+	flush()
+
+	return chunks, nil
+}
+
+// createChunk creates a chunk spanning a run of units
+func (c *ProseChunker) createChunk(filePath string, text string, language string, units []proseUnit, breadcrumb string, symbolTable *model.SymbolTable) model.Chunk {
+	start := units[0].start
+	end := units[len(units)-1].end
+	content := text[start:end]
+	chunkID := util.GenerateID(filePath, content)
+	startLine := strings.Count(text[:start], "\n") + 1
+	endLine := startLine + strings.Count(content, "\n")
+
+	var symbols []string
+	if breadcrumb != "" {
+		symbols = []string{breadcrumb}
+		symbolTable.AddDefinition(breadcrumb, model.SymbolDefinition{
+			Name:      breadcrumb,
+			ChunkID:   chunkID,
+			FilePath:  filePath,
+			StartLine: startLine,
+			EndLine:   endLine,
+			Type:      "section",
+		})
+	}
+
+	return model.Chunk{
+		ID:         chunkID,
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   language,
+		Symbols:    symbols,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+}
+
+// parseProseUnits splits a document into headings and paragraphs. Paragraphs are
+// separated by blank lines, except inside AsciiDoc delimited blocks
+func parseProseUnits(text string, language string) []proseUnit {
+	lines := strings.Split(text, "\n")
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line) + 1
+	}
+	lineEnd := func(i int) int {
+		return offsets[i] + len(lines[i])
+	}
+
+	var units []proseUnit
+	rstLevels := make(map[string]int)
+	paraStart := -1
+	delimiter := ""
+
+	endParagraph := func(last int) {
+		if paraStart >= 0 {
+			units = append(units, proseUnit{start: offsets[paraStart], end: lineEnd(last)})
+			paraStart = -1
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if language == "asciidoc" {
+			if delimiter != "" {
+				if trimmed == delimiter {
+					delimiter = ""
+				}
+				continue
+			}
+			if asciidocDelimiterPattern.MatchString(trimmed) {
+				if paraStart < 0 {
+					paraStart = i
+				}
+				delimiter = trimmed
+				continue
+			}
+			if match := asciidocHeadingPattern.FindStringSubmatch(lines[i]); match != nil {
+				endParagraph(i - 1)
+				units = append(units, proseUnit{
+					start:   offsets[i],
+					end:     lineEnd(i),
+					heading: true,
+					level:   len(match[1]) - 1,
+					title:   strings.TrimSpace(match[2]),
+				})
+				continue
+			}
+		}
+
+		if language == "rst" && paraStart < 0 {
+			if first, last, style := rstHeading(lines, i); last > 0 {
+				if _, ok := rstLevels[style]; !ok {
+					rstLevels[style] = len(rstLevels)
+				}
+				units = append(units, proseUnit{
+					start:   offsets[first],
+					end:     lineEnd(last),
+					heading: true,
+					level:   rstLevels[style],
+					title:   strings.TrimSpace(lines[last-1]),
+				})
+				i = last
+				continue
+			}
+		}
+
+		if trimmed == "" {
+			endParagraph(i - 1)
+			continue
+		}
+		if paraStart < 0 {
+			paraStart = i
+		}
+	}
+	endParagraph(len(lines) - 1)
+
+	return units
+}
+
+// rstHeading detects a reStructuredText section title starting at line i: a
+// title with an underline, optionally with a matching overline. It returns the
+// first and last line of the heading and its adornment style, or last == 0
+func rstHeading(lines []string, i int) (int, int, string) {
+	overline := ""
+	title := i
+	if isRSTAdornment(lines[i]) && i+2 < len(lines) {
+		overline = lines[i]
+		title = i + 1
+	}
+	if title+1 >= len(lines) {
+		return 0, 0, ""
+	}
+
+	titleText := strings.TrimSpace(lines[title])
+	underline := strings.TrimRight(lines[title+1], " \t")
+	if titleText == "" || isRSTAdornment(titleText) ||
+		!isRSTAdornment(underline) || len(underline) < len(titleText) {
+		return 0, 0, ""
+	}
+
+	if overline != "" {
+		if strings.TrimRight(overline, " \t") != underline {
+			return 0, 0, ""
+		}
+		return i, title + 1, "over" + underline[:1]
+	}
+	return i, title + 1, underline[:1]
+}
+
+// rstAdornmentChars are the punctuation characters reStructuredText allows in section adornments
+const rstAdornmentChars = "=-`:'\"~^_*+#<>."
+
+// isRSTAdornment checks if a line is a run of one repeated adornment character
+func isRSTAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 || !strings.ContainsRune(rstAdornmentChars, rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+// splitSentences splits an oversized paragraph into runs of sentences that fit
+// the budget. A single sentence over budget is split between words
+func splitSentences(text string, paragraph proseUnit, budget int) []proseUnit {
+	body := text[paragraph.start:paragraph.end]
+	maxChars := budget * 4
+
+	// Sentence boundaries, as offsets into the document
+	var bounds []int
+	for _, match := range sentenceEndPattern.FindAllStringIndex(body, -1) {
+		bounds = append(bounds, paragraph.start+match[1])
+	}
+	bounds = append(bounds, paragraph.end)
+
+	var units []proseUnit
+	start := paragraph.start
+	prev := paragraph.start
+	for _, bound := range bounds {
+		if bound-start > maxChars && prev > start {
+			units = append(units, proseUnit{start: start, end: trimRightOffset(text, start, prev)})
+			start = prev
+		}
+		for bound-start > maxChars {
+			// Cut the oversized sentence at the last space that fits
+			cut := strings.LastIndexAny(text[start:start+maxChars], " \n\t")
+			if cut <= 0 {
+				// No space to cut at, so cut at the last rune boundary
+				cut = maxChars
+				for cut > 0 && !utf8.RuneStart(text[start+cut]) {
+					cut--
+				}
+				if cut == 0 {
+					_, cut = utf8.DecodeRuneInString(text[start:])
+				}
+			}
+			units = append(units, proseUnit{start: start, end: trimRightOffset(text, start, start+cut)})
+			start += cut
+			for start < bound && strings.ContainsRune(" \n\t", rune(text[start])) {
+				start++
+			}
+		}
+		prev = bound
+	}
+	if start < paragraph.end {
+		units = append(units, proseUnit{start: start, end: paragraph.end})
+	}
+
+	return units
+}
+
+// trimRightOffset moves an end offset back over trailing whitespace
+func trimRightOffset(text string, start, end int) int {
+	for end > start && strings.ContainsRune(" \n\t", rune(text[end-1])) {
+		end--
+	}
+	return end
+}
+
+// onlyHeadings checks if a run of units holds nothing but headings
+func onlyHeadings(units []proseUnit) bool {
+	for _, unit := range units {
+		if !unit.heading {
+			return false
+		}
+	}
+	return true
+}
+
+// proseLanguage determines the markup language from the file extension
+func proseLanguage(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".rst":
+		return "rst"
+	case ".adoc", ".asciidoc":
+		return "asciidoc"
+	}
+	return "text"
+}
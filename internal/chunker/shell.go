@@ -1,8 +1,8 @@
 package chunker
 
 import (
-	"bytes"
-	"regexp"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/stream-ai/chunk/internal/model"
@@ -27,73 +27,201 @@ func (c *ShellChunker) CanHandle(filePath string, language string, framework str
 	return language == "shell" || language == "bash" || language == "zsh"
 }
 
-// Chunk splits shell script content into chunks
+// shellCluster is a group of top-level commands sharing lines, chunked together
+type shellCluster struct {
+	startLine int
+	endLine   int
+	commands  []*shellCommand
+	function  bool // whether one of the commands is a function definition
+}
+
+// Chunk splits a shell script into one chunk per function definition, with
+// the comments directly above it, and chunks of the remaining top-level
+// code. Chunks are cut between commands only, so heredocs, multi-line
+// strings and compound commands are never split
 func (c *ShellChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
-	lines := bytes.Split(content, []byte("\n"))
+	lines := strings.Split(string(content), "\n")
+	commands := parseShell(string(content))
+
+	// Commands on the same lines, like `f() { ...; }; f`, can't be told apart
+	var clusters []*shellCluster
+	for _, command := range commands {
+		if n := len(clusters); n > 0 && command.startLine <= clusters[n-1].endLine {
+			last := clusters[n-1]
+			last.endLine = max(last.endLine, command.endLine)
+			last.commands = append(last.commands, command)
+			last.function = last.function || command.function != ""
+			continue
+		}
+		clusters = append(clusters, &shellCluster{
+			startLine: command.startLine,
+			endLine:   command.endLine,
+			commands:  []*shellCommand{command},
+			function:  command.function != "",
+		})
+	}
+
 	var chunks []model.Chunk
+	next := 1 // the first line not chunked yet
+	var code []*shellCluster
+
+	// flushCode chunks the top-level code up to a line, cut between commands
+	flushCode := func(endLine int) {
+		canBreak := func(line int) bool {
+			for _, cluster := range code {
+				if line >= cluster.startLine && line < cluster.endLine {
+					return false
+				}
+			}
+			return true
+		}
+		for _, part := range shellSplit(lines, next, endLine, options.MaxChunkSize, 0, canBreak) {
+			var symbols []string
+			for _, cluster := range code {
+				symbols = append(symbols, shellFunctions(cluster.commands, part[0], part[1])...)
+			}
+			chunks = append(chunks, c.createChunk(filePath, lines[part[0]-1:part[1]], part[0], part[1], symbols, "", symbolTable))
+		}
+		code = nil
+	}
+
+	for _, cluster := range clusters {
+		if !cluster.function {
+			code = append(code, cluster)
+			continue
+		}
+
+		// The comments directly above a function document it
+		startLine := cluster.startLine
+		for startLine > next && isShellComment(lines[startLine-2]) {
+			startLine--
+		}
+		flushCode(startLine - 1)
+		chunks = append(chunks, c.functionChunks(filePath, lines, cluster, startLine, symbolTable, options)...)
+		next = cluster.endLine + 1
+	}
+	flushCode(len(lines))
+
+	c.addRelations(filePath, commands, chunks, symbolTable)
+
+	return chunks, nil
+}
+
+// functionChunks creates the chunk of a function definition, or one chunk per
+// part if the function is longer than MaxChunkSize. As for Go functions, the
+// first part keeps the function's symbol, and every later part repeats the
+// lines up to the opening of the body as a header and is named name#partN
+// with a link to the first part
+func (c *ShellChunker) functionChunks(filePath string, lines []string, cluster *shellCluster, startLine int, symbolTable *model.SymbolTable, options ChunkingOptions) []model.Chunk {
+	function := cluster.commands[0]
+	if len(cluster.commands) > 1 || len(function.body) == 0 || options.MaxChunkSize <= 0 || cluster.endLine-startLine+1 <= options.MaxChunkSize {
+		symbols := shellFunctions(cluster.commands, startLine, cluster.endLine)
+		return []model.Chunk{c.createChunk(filePath, lines[startLine-1:cluster.endLine], startLine, cluster.endLine, symbols, "", symbolTable)}
+	}
 
-	// Find function definitions and block boundaries
-	var currentChunk [][]byte
-	startLine := 1
-	inFunction := false
-
-	for i, line := range lines {
-		lineStr := string(line)
-		trimmedLine := strings.TrimSpace(lineStr)
-
-		// Check for function definition
-		if isFunctionStart(trimmedLine) && !inFunction {
-			// If we have accumulated lines, create a chunk
-			if len(currentChunk) > 0 {
-				chunk := c.createChunk(filePath, currentChunk, startLine, i, symbolTable)
-				chunks = append(chunks, chunk)
-				currentChunk = [][]byte{}
+	// The body is cut between its commands, keeping at least one command in
+	// the first part and the closing line with the last
+	body := function.body
+	header := lines[function.startLine-1 : function.bodyLine]
+	canBreak := func(line int) bool {
+		if line < body[0].endLine || line >= body[len(body)-1].endLine {
+			return false
+		}
+		for _, command := range body {
+			if line >= command.startLine && line < command.endLine {
+				return false
 			}
+		}
+		return true
+	}
 
-			inFunction = true
-			startLine = i + 1
+	var chunks []model.Chunk
+	for i, part := range shellSplit(lines, startLine, cluster.endLine, options.MaxChunkSize, len(header), canBreak) {
+		partContent := lines[part[0]-1 : part[1]]
+		symbols := shellFunctions(cluster.commands, part[0], part[1])
+		parentID := ""
+		if i > 0 {
+			partContent = append(append([]string{}, header...), partContent...)
+			symbols = append([]string{fmt.Sprintf("%s#part%d", function.function, i+1)}, symbols...)
+			parentID = chunks[0].ID
 		}
+		chunks = append(chunks, c.createChunk(filePath, partContent, part[0], part[1], symbols, parentID, symbolTable))
+	}
+	return chunks
+}
 
-		// Check for end of function
-		if inFunction && (trimmedLine == "}" || strings.HasPrefix(trimmedLine, "} #")) {
-			currentChunk = append(currentChunk, line)
-			chunk := c.createChunk(filePath, currentChunk, startLine, i+1, symbolTable)
-			chunks = append(chunks, chunk)
-			currentChunk = [][]byte{}
-			inFunction = false
-			startLine = i + 2
-			continue
+// shellSplit splits lines from to to into parts of at most maxSize lines,
+// headerSize less for every part but the first, cut only after lines where
+// canBreak allows it. A part that can't be cut in time runs to the next
+// allowed cut. Blank lines are trimmed from the ends of the parts, and
+// parts holding only blank lines are dropped
+func shellSplit(lines []string, from, to int, maxSize, headerSize int, canBreak func(line int) bool) [][2]int {
+	to = min(to, len(lines))
+
+	var parts [][2]int
+	for from <= to {
+		budget := maxSize
+		if len(parts) > 0 {
+			budget = max(maxSize-headerSize, 1)
 		}
 
-		// Add line to current chunk
-		currentChunk = append(currentChunk, line)
+		end := to
+		if maxSize > 0 && to-from+1 > budget {
+			end = 0
+			for line := from + budget - 1; line >= from && end == 0; line-- {
+				if canBreak(line) {
+					end = line
+				}
+			}
+			if end == 0 {
+				for end = from + budget; end < to && !canBreak(end); end++ {
+				}
+			}
+		}
 
-		// If we have accumulated many lines outside a function, create a chunk
-		if !inFunction && len(currentChunk) >= options.MaxChunkSize {
-			chunk := c.createChunk(filePath, currentChunk, startLine, i+1, symbolTable)
-			chunks = append(chunks, chunk)
-			currentChunk = [][]byte{}
-			startLine = i + 2
+		start, last := from, end
+		for start <= last && strings.TrimSpace(lines[start-1]) == "" {
+			start++
+		}
+		for last >= start && strings.TrimSpace(lines[last-1]) == "" {
+			last--
 		}
+		if start <= last {
+			parts = append(parts, [2]int{start, last})
+		}
+		from = end + 1
 	}
+	return parts
+}
 
-	// Add any remaining lines
-	if len(currentChunk) > 0 {
-		chunk := c.createChunk(filePath, currentChunk, startLine, len(lines), symbolTable)
-		chunks = append(chunks, chunk)
+// shellFunctions returns the names of the functions defined by commands,
+// nested ones included, that start between two lines
+func shellFunctions(commands []*shellCommand, from, to int) []string {
+	var names []string
+	for _, command := range commands {
+		if command.function != "" && command.startLine >= from && command.startLine <= to && !slices.Contains(names, command.function) {
+			names = append(names, command.function)
+		}
+		for _, name := range shellFunctions(command.body, from, to) {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
 	}
+	return names
+}
 
-	return chunks, nil
+// isShellComment checks if a line is a comment, other than a shebang
+func isShellComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "#!")
 }
 
-// createChunk creates a chunk from lines
-func (c *ShellChunker) createChunk(filePath string, lines [][]byte, startLine, endLine int, symbolTable *model.SymbolTable) model.Chunk {
-	content := string(bytes.Join(lines, []byte("\n")))
+// createChunk creates a chunk from lines, defining its symbols as functions
+func (c *ShellChunker) createChunk(filePath string, lines []string, startLine, endLine int, symbols []string, parentID string, symbolTable *model.SymbolTable) model.Chunk {
+	content := strings.Join(lines, "\n")
 	chunkID := util.GenerateID(filePath, content)
 
-	// Extract function names as symbols
-	symbols := c.extractFunctions(content)
-
 	chunk := model.Chunk{
 		ID:         chunkID,
 		FilePath:   filePath,
@@ -103,6 +231,7 @@ func (c *ShellChunker) createChunk(filePath string, lines [][]byte, startLine, e
 		Language:   "shell",
 		Symbols:    symbols,
 		TokenCount: util.EstimateTokenCount(content),
+		ParentID:   parentID,
 	}
 
 	// Add symbols to symbol table
@@ -119,56 +248,3 @@ func (c *ShellChunker) createChunk(filePath string, lines [][]byte, startLine, e
 
 	return chunk
 }
-
-// isFunctionStart checks if a line is a function definition
-func isFunctionStart(line string) bool {
-	// Match patterns like:
-	// function name() {
-	// function name {
-	// name() {
-	if strings.HasPrefix(line, "function ") && (strings.Contains(line, "()") || strings.HasSuffix(line, "{")) {
-		return true
-	}
-
-	// Check for name() { pattern
-	funcPattern := regexp.MustCompile(`^\s*\w+\s*\(\s*\)\s*\{`)
-	return funcPattern.MatchString(line)
-}
-
-// extractFunctions extracts function names from shell script content
-func (c *ShellChunker) extractFunctions(content string) []string {
-	var symbols []string
-
-	// Look for function definitions: function name() or name()
-	funcPattern1 := regexp.MustCompile(`function\s+(\w+)\s*\(\s*\)`)
-	funcPattern2 := regexp.MustCompile(`function\s+(\w+)\s*\{`)
-	funcPattern3 := regexp.MustCompile(`\b(\w+)\s*\(\s*\)\s*\{`)
-
-	matches1 := funcPattern1.FindAllStringSubmatch(content, -1)
-	for _, match := range matches1 {
-		symbols = append(symbols, match[1])
-	}
-
-	matches2 := funcPattern2.FindAllStringSubmatch(content, -1)
-	for _, match := range matches2 {
-		symbols = append(symbols, match[1])
-	}
-
-	matches3 := funcPattern3.FindAllStringSubmatch(content, -1)
-	for _, match := range matches3 {
-		symbols = append(symbols, match[1])
-	}
-
-	// Deduplicate
-	symbolMap := make(map[string]bool)
-	for _, symbol := range symbols {
-		symbolMap[symbol] = true
-	}
-
-	var uniqueSymbols []string
-	for symbol := range symbolMap {
-		uniqueSymbols = append(uniqueSymbols, symbol)
-	}
-
-	return uniqueSymbols
-}
@@ -0,0 +1,736 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+)
+
+// shellCommand is a command of a shell script with the lines it spans,
+// including the bodies of its heredocs
+type shellCommand struct {
+	startLine int
+	endLine   int
+	function  string          // the name of a function definition
+	bodyLine  int             // the line a function's body opens on
+	words     []shellWord     // the words of a simple command, without redirections
+	body      []*shellCommand // the commands of a compound command, function body or pipeline, and of command substitutions
+	vars      []string        // the variables expanded by the command's own words
+}
+
+// addToken records the command substitutions and expansions of a word used by a command
+func (c *shellCommand) addToken(tok shellToken) {
+	c.body = append(c.body, tok.substs...)
+	c.vars = append(c.vars, tok.vars...)
+}
+
+// shellWord is a word of a simple command
+type shellWord struct {
+	text    string // as written
+	value   string // with quotes and escapes removed
+	literal bool   // whether the word holds no expansions or substitutions
+	line    int
+}
+
+// shellToken is a word, an operator, a newline or the end of the script
+type shellToken struct {
+	kind   string // "word", "op", "arith", "newline" or "eof"
+	text   string
+	word   shellWord
+	substs []*shellCommand // the commands of the word's command substitutions
+	vars   []string        // the variables the word expands
+	line   int
+	end    int // the last line of the token, or of the heredocs read after a newline
+}
+
+// is reports whether the token is the given operator or unquoted word
+func (t shellToken) is(text string) bool {
+	return (t.kind == "op" || t.kind == "word") && t.text == text
+}
+
+// shellHeredoc is a heredoc whose body starts after the next newline
+type shellHeredoc struct {
+	delimiter string
+	stripTabs bool
+}
+
+// shellOperators are the control and redirection operators, longest first
+var shellOperators = []string{
+	";;&", "&>>", "<<<", "<<-",
+	";;", ";&", "&&", "||", "|&", "&>", "<<", ">>", ">&", "<&", "<>", ">|",
+	";", "&", "|", "(", ")", "<", ">",
+}
+
+// shellRedirections are the operators followed by a file or heredoc delimiter
+var shellRedirections = map[string]bool{
+	"<": true, ">": true, ">>": true, ">|": true, "<>": true, "<&": true, ">&": true,
+	"&>": true, "&>>": true, "<<": true, "<<-": true, "<<<": true,
+}
+
+// shellListEnds are the reserved words ending a list of commands
+var shellListEnds = map[string]bool{
+	"}": true, "then": true, "elif": true, "else": true, "fi": true,
+	"do": true, "done": true, "esac": true,
+}
+
+// shellArrayAssignment matches the start of a word assigning an array, name=(
+var shellArrayAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\[[^\]]*\])?\+?=$`)
+
+// shellParser is a recursive descent parser for POSIX shell and bash. The
+// lexer is driven by the parser, so command substitutions are parsed in
+// place and case patterns, heredocs and quoting never end a block early
+type shellParser struct {
+	src      string
+	pos      int
+	line     int
+	peeked   *shellToken
+	lastEnd  int
+	heredocs []shellHeredoc
+	heredoc  string // the operator of a heredoc whose delimiter is the next word
+}
+
+// parseShell parses a shell script into its top-level commands. The parser
+// recovers from syntax errors: unterminated constructs end with the script,
+// and stray closing words and operators are skipped
+func parseShell(content string) []*shellCommand {
+	return newShellParser(content, 1).parseScript()
+}
+
+func newShellParser(content string, line int) *shellParser {
+	return &shellParser{src: content, line: line, lastEnd: line}
+}
+
+func (p *shellParser) parseScript() []*shellCommand {
+	var commands []*shellCommand
+	for {
+		commands = append(commands, p.parseList()...)
+		if p.next().kind == "eof" {
+			return commands
+		}
+	}
+}
+
+// parseList parses commands separated by ;, & and newlines, up to a
+// closing parenthesis, a case item terminator, a reserved word ending a
+// block or the end of the script
+func (p *shellParser) parseList() []*shellCommand {
+	var commands []*shellCommand
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == "eof", tok.is(")"), tok.is(";;"), tok.is(";&"), tok.is(";;&"):
+			return commands
+		case tok.kind == "word" && shellListEnds[tok.text]:
+			return commands
+		case tok.kind == "newline", tok.is(";"), tok.is("&"):
+			p.next()
+			// Heredocs read after the newline belong to the command before it
+			if tok.kind == "newline" && tok.end > tok.line && len(commands) > 0 {
+				last := commands[len(commands)-1]
+				last.endLine = max(last.endLine, tok.end)
+			}
+			continue
+		}
+
+		command := p.parseAndOr()
+		if command == nil {
+			// An operator that can't start a command
+			p.next()
+			continue
+		}
+		commands = append(commands, command)
+	}
+}
+
+// parseAndOr parses pipelines joined by && and ||
+func (p *shellParser) parseAndOr() *shellCommand {
+	first := p.parsePipeline()
+	if first == nil {
+		return nil
+	}
+	parts := []*shellCommand{first}
+	for p.peek().is("&&") || p.peek().is("||") {
+		p.next()
+		p.skipNewlines()
+		if part := p.parsePipeline(); part != nil {
+			parts = append(parts, part)
+		}
+	}
+	return shellJoin(parts)
+}
+
+// parsePipeline parses commands joined by | and |&, with an optional ! or time
+func (p *shellParser) parsePipeline() *shellCommand {
+	for p.peek().is("!") || p.peek().is("time") {
+		p.next()
+	}
+	first := p.parseCommand()
+	if first == nil {
+		return nil
+	}
+	parts := []*shellCommand{first}
+	for p.peek().is("|") || p.peek().is("|&") {
+		p.next()
+		p.skipNewlines()
+		if part := p.parseCommand(); part != nil {
+			parts = append(parts, part)
+		}
+	}
+	return shellJoin(parts)
+}
+
+// shellJoin returns the single command of a pipeline or list, or a command
+// spanning all of them
+func shellJoin(parts []*shellCommand) *shellCommand {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return &shellCommand{
+		startLine: parts[0].startLine,
+		endLine:   parts[len(parts)-1].endLine,
+		body:      parts,
+	}
+}
+
+// parseCommand parses a simple command, a compound command or a function
+// definition, along with its redirections
+func (p *shellParser) parseCommand() *shellCommand {
+	tok := p.peek()
+	command := &shellCommand{startLine: tok.line}
+
+	switch {
+	case tok.is("("):
+		p.next()
+		command.body = p.parseList()
+		p.expect(")")
+	case tok.kind == "arith":
+		p.next()
+	case tok.is("{"):
+		p.next()
+		command.body = p.parseList()
+		p.expect("}")
+	case tok.is("if"):
+		p.parseIf(command)
+	case tok.is("while"), tok.is("until"):
+		p.next()
+		command.body = p.parseList()
+		command.body = append(command.body, p.parseDoGroup()...)
+	case tok.is("for"), tok.is("select"):
+		p.parseFor(command)
+	case tok.is("case"):
+		p.parseCase(command)
+	case tok.is("[["):
+		p.next()
+		for tok := p.peek(); tok.kind != "eof" && !tok.is("]]"); tok = p.peek() {
+			command.addToken(p.next())
+		}
+		p.expect("]]")
+	case tok.is("function"):
+		p.next()
+		name := p.next()
+		if p.peek().is("(") {
+			p.next()
+			p.expect(")")
+		}
+		p.parseFunctionBody(command, name.word.value)
+		return command
+	case tok.kind == "word" || (tok.kind == "op" && shellRedirections[tok.text]):
+		if !p.parseSimpleCommand(command) {
+			return command
+		}
+	default:
+		return nil
+	}
+
+	p.parseRedirections(command)
+	command.endLine = p.lastEnd
+	return command
+}
+
+// parseSimpleCommand parses the words and redirections of a simple command.
+// It returns false if the command turned out to be a function definition
+func (p *shellParser) parseSimpleCommand(command *shellCommand) bool {
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == "word":
+			p.next()
+			command.words = append(command.words, tok.word)
+			command.addToken(tok)
+
+			// name() starts a function definition
+			if len(command.words) == 1 && p.peek().is("(") {
+				p.next()
+				p.expect(")")
+				command.words = nil
+				p.parseFunctionBody(command, tok.word.value)
+				return false
+			}
+		case tok.kind == "op" && shellRedirections[tok.text]:
+			p.parseRedirections(command)
+		default:
+			command.endLine = p.lastEnd
+			return true
+		}
+	}
+}
+
+// parseFunctionBody parses the compound command of a function definition,
+// which may start on a later line
+func (p *shellParser) parseFunctionBody(command *shellCommand, name string) {
+	command.function = name
+	p.skipNewlines()
+	tok := p.peek()
+	command.bodyLine = tok.line
+
+	body := p.parseCommand()
+	switch {
+	case body == nil:
+		command.endLine = p.lastEnd
+		return
+	case tok.is("{") || tok.is("("):
+		command.body = body.body
+	default:
+		command.body = []*shellCommand{body}
+	}
+	command.endLine = body.endLine
+}
+
+// parseIf parses if, elif and else clauses up to fi
+func (p *shellParser) parseIf(command *shellCommand) {
+	p.next()
+	for {
+		command.body = append(command.body, p.parseList()...)
+		tok := p.peek()
+		if !tok.is("then") && !tok.is("elif") && !tok.is("else") {
+			break
+		}
+		p.next()
+	}
+	p.expect("fi")
+}
+
+// parseFor parses for and select loops, including the arithmetic form
+func (p *shellParser) parseFor(command *shellCommand) {
+	p.next()
+	if p.peek().kind == "arith" {
+		p.next()
+	} else {
+		p.next()
+		p.skipNewlines()
+		if p.peek().is("in") {
+			p.next()
+			for p.peek().kind == "word" {
+				command.addToken(p.next())
+			}
+		}
+	}
+	if tok := p.peek(); tok.is(";") || tok.kind == "newline" {
+		p.next()
+	}
+	p.skipNewlines()
+
+	// bash also accepts a brace group as the loop body
+	if p.peek().is("{") {
+		if body := p.parseCommand(); body != nil {
+			command.body = append(command.body, body.body...)
+		}
+		return
+	}
+	command.body = append(command.body, p.parseDoGroup()...)
+}
+
+// parseDoGroup parses a loop body, do ... done
+func (p *shellParser) parseDoGroup() []*shellCommand {
+	if !p.peek().is("do") {
+		return nil
+	}
+	p.next()
+	body := p.parseList()
+	p.expect("done")
+	return body
+}
+
+// parseCase parses a case statement, whose patterns end with a closing
+// parenthesis and whose items end with ;;, ;& or ;;&
+func (p *shellParser) parseCase(command *shellCommand) {
+	p.next()
+	command.addToken(p.next())
+	p.skipNewlines()
+	p.expect("in")
+
+	for {
+		p.skipNewlines()
+		tok := p.peek()
+		if tok.kind == "eof" || tok.is("esac") {
+			break
+		}
+
+		// The pattern list, with an optional opening parenthesis
+		if tok.is("(") {
+			p.next()
+		}
+		for tok := p.peek(); tok.kind != "eof" && !tok.is(")"); tok = p.peek() {
+			command.addToken(p.next())
+		}
+		p.expect(")")
+
+		command.body = append(command.body, p.parseList()...)
+		if tok := p.peek(); tok.is(";;") || tok.is(";&") || tok.is(";;&") {
+			p.next()
+		} else if !tok.is("esac") {
+			// A reserved word that doesn't belong here ends the statement
+			break
+		}
+	}
+	p.expect("esac")
+}
+
+// parseRedirections parses the redirections following a command
+func (p *shellParser) parseRedirections(command *shellCommand) {
+	for tok := p.peek(); tok.kind == "op" && shellRedirections[tok.text]; tok = p.peek() {
+		p.next()
+		if p.peek().kind == "word" {
+			command.addToken(p.next())
+		}
+	}
+}
+
+// skipNewlines skips newlines, reading the heredocs they start
+func (p *shellParser) skipNewlines() {
+	for p.peek().kind == "newline" {
+		p.next()
+	}
+}
+
+// expect skips a closing word or operator, if it's there
+func (p *shellParser) expect(text string) {
+	if p.peek().is(text) {
+		p.next()
+	}
+}
+
+func (p *shellParser) peek() shellToken {
+	if p.peeked == nil {
+		tok := p.lex()
+		p.peeked = &tok
+	}
+	return *p.peeked
+}
+
+func (p *shellParser) next() shellToken {
+	tok := p.peek()
+	p.peeked = nil
+	if tok.kind != "newline" && tok.kind != "eof" {
+		p.lastEnd = tok.end
+	}
+	return tok
+}
+
+// lex reads the next token, skipping blanks, line continuations and comments
+func (p *shellParser) lex() shellToken {
+	for p.pos < len(p.src) {
+		switch ch := p.src[p.pos]; {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			p.pos++
+		case ch == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\n':
+			p.pos += 2
+			p.line++
+		case ch == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return p.lexToken()
+		}
+	}
+	return shellToken{kind: "eof", line: p.line, end: p.line}
+}
+
+func (p *shellParser) lexToken() shellToken {
+	line := p.line
+	rest := p.src[p.pos:]
+
+	if rest[0] == '\n' {
+		p.pos++
+		p.line++
+		p.readHeredocs()
+		return shellToken{kind: "newline", line: line, end: p.line - 1}
+	}
+
+	// Arithmetic commands, ((...)), are kept whole
+	if strings.HasPrefix(rest, "((") {
+		if end := shellClosing(rest, 2, '(', ')'); end > 0 && end+1 < len(rest) && rest[end+1] == ')' {
+			p.pos += end + 2
+			p.line += strings.Count(rest[:end+2], "\n")
+			return shellToken{kind: "arith", text: rest[:end+2], line: line, end: p.line}
+		}
+	}
+
+	// Process substitutions are words
+	if !strings.HasPrefix(rest, "<(") && !strings.HasPrefix(rest, ">(") {
+		for _, op := range shellOperators {
+			if strings.HasPrefix(rest, op) {
+				p.pos += len(op)
+				if op == "<<" || op == "<<-" {
+					p.heredoc = op
+				}
+				return shellToken{kind: "op", text: op, line: line, end: line}
+			}
+		}
+	}
+
+	return p.lexWord()
+}
+
+// lexWord reads a word, following quotes, escapes, parameter expansions and
+// command, arithmetic and process substitutions
+func (p *shellParser) lexWord() shellToken {
+	tok := shellToken{kind: "word", line: p.line}
+	start := p.pos
+	var value strings.Builder
+	literal := true
+
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if strings.IndexByte(" \t\r\n;&|<>()", ch) >= 0 {
+			if ch == '(' && p.pos > start {
+				// Array assignments and extended globs hold parentheses
+				prefix := p.src[start:p.pos]
+				if shellArrayAssignment.MatchString(prefix) || strings.ContainsRune("?*+@!", rune(prefix[len(prefix)-1])) {
+					end := p.skipBalanced(p.pos+1, '(', ')')
+					value.WriteString(p.src[p.pos:end])
+					p.pos = end
+					continue
+				}
+			}
+			if (ch == '<' || ch == '>') && p.pos+1 < len(p.src) && p.src[p.pos+1] == '(' && p.pos == start {
+				p.pos += 2
+				tok.substs = append(tok.substs, p.parseSubstitution()...)
+				literal = false
+				continue
+			}
+			break
+		}
+
+		switch ch {
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				if p.src[p.pos+1] == '\n' {
+					p.line++
+				} else {
+					value.WriteByte(p.src[p.pos+1])
+				}
+				p.pos += 2
+			} else {
+				p.pos++
+			}
+		case '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				end = len(p.src) - p.pos - 1
+			}
+			quoted := p.src[p.pos+1 : p.pos+1+end]
+			value.WriteString(quoted)
+			p.line += strings.Count(quoted, "\n")
+			p.pos = min(p.pos+end+2, len(p.src))
+		case '"':
+			p.pos++
+			for p.pos < len(p.src) && p.src[p.pos] != '"' {
+				switch c := p.src[p.pos]; c {
+				case '\\':
+					if p.pos+1 < len(p.src) && strings.IndexByte("$`\"\\\n", p.src[p.pos+1]) >= 0 {
+						p.pos++
+					}
+					p.writeByte(&value, p.src[p.pos])
+					p.pos++
+				case '$', '`':
+					if !p.lexExpansion(&tok, &value) {
+						value.WriteByte(c)
+						p.pos++
+					} else {
+						literal = false
+					}
+				default:
+					p.writeByte(&value, c)
+					p.pos++
+				}
+			}
+			p.pos = min(p.pos+1, len(p.src))
+		case '$', '`':
+			if strings.HasPrefix(p.src[p.pos:], "$'") {
+				// ANSI-C quoting, with backslash escapes
+				for p.pos += 2; p.pos < len(p.src) && p.src[p.pos] != '\''; p.pos++ {
+					if p.src[p.pos] == '\\' {
+						p.pos++
+					}
+					if p.pos < len(p.src) {
+						p.writeByte(&value, p.src[p.pos])
+					}
+				}
+				p.pos = min(p.pos+1, len(p.src))
+				continue
+			}
+			if p.lexExpansion(&tok, &value) {
+				literal = false
+			} else {
+				value.WriteByte(ch)
+				p.pos++
+			}
+		default:
+			value.WriteByte(ch)
+			p.pos++
+		}
+	}
+
+	tok.text = p.src[start:p.pos]
+	tok.word = shellWord{text: tok.text, value: value.String(), literal: literal, line: tok.line}
+	tok.end = p.line
+
+	if p.heredoc != "" {
+		p.heredocs = append(p.heredocs, shellHeredoc{delimiter: tok.word.value, stripTabs: p.heredoc == "<<-"})
+		p.heredoc = ""
+	}
+	return tok
+}
+
+// lexExpansion reads a parameter expansion or a command or arithmetic
+// substitution, writing it to the word's value as written. It returns false
+// if the $ or ` doesn't start one
+func (p *shellParser) lexExpansion(tok *shellToken, value *strings.Builder) bool {
+	start := p.pos
+	rest := p.src[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, "$(("):
+		p.pos = p.skipBalanced(p.pos+2, '(', ')')
+	case strings.HasPrefix(rest, "$("):
+		p.pos += 2
+		tok.substs = append(tok.substs, p.parseSubstitution()...)
+	case strings.HasPrefix(rest, "${"):
+		p.pos = p.skipBalanced(p.pos+2, '{', '}')
+		if name := shellName(strings.TrimLeft(rest[2:], "#!")); name != "" {
+			tok.vars = append(tok.vars, name)
+		}
+	case rest[0] == '`':
+		line := p.line
+		p.pos++
+		var inner strings.Builder
+		for p.pos < len(p.src) && p.src[p.pos] != '`' {
+			if p.src[p.pos] == '\\' && p.pos+1 < len(p.src) && strings.IndexByte("$`\\", p.src[p.pos+1]) >= 0 {
+				p.pos++
+			}
+			p.writeByte(&inner, p.src[p.pos])
+			p.pos++
+		}
+		p.pos = min(p.pos+1, len(p.src))
+		tok.substs = append(tok.substs, newShellParser(inner.String(), line).parseScript()...)
+	case len(rest) > 1 && (isShellNameChar(rest[1]) || strings.IndexByte("@*#?$!-", rest[1]) >= 0):
+		p.pos += 2
+		if name := shellName(rest[1:]); name != "" {
+			p.pos += len(name) - 1
+			tok.vars = append(tok.vars, name)
+		}
+	default:
+		return false
+	}
+	value.WriteString(p.src[start:p.pos])
+	return true
+}
+
+// parseSubstitution parses the commands of a command or process substitution
+// up to its closing parenthesis, with the lexer just past the opening one
+func (p *shellParser) parseSubstitution() []*shellCommand {
+	peeked, heredoc := p.peeked, p.heredoc
+	p.peeked, p.heredoc = nil, ""
+	commands := p.parseList()
+	p.expect(")")
+	p.peeked, p.heredoc = peeked, heredoc
+	return commands
+}
+
+// skipBalanced returns the offset just past the closing delimiter matching
+// an opening one before offset i, skipping quoted text
+func (p *shellParser) skipBalanced(i int, open, close byte) int {
+	end := shellClosing(p.src, i, open, close)
+	if end < 0 {
+		end = len(p.src)
+	} else {
+		end++
+	}
+	p.line += strings.Count(p.src[p.pos:end], "\n")
+	return end
+}
+
+// shellClosing returns the offset of the delimiter closing an opening one
+// before offset i, skipping quoted text, or -1 if there is none
+func shellClosing(src string, i int, open, close byte) int {
+	depth := 1
+	for ; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '\'', '"', '`':
+			quote := src[i]
+			for i++; i < len(src) && src[i] != quote; i++ {
+				if src[i] == '\\' && quote != '\'' {
+					i++
+				}
+			}
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// readHeredocs skips the bodies of the heredocs started on the line just
+// ended, each up to the line holding only its delimiter
+func (p *shellParser) readHeredocs() {
+	for _, heredoc := range p.heredocs {
+		for p.pos < len(p.src) {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			line := strings.TrimSuffix(p.src[p.pos:p.pos+end], "\r")
+			if heredoc.stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			p.pos = min(p.pos+end+1, len(p.src))
+			p.line++
+			if line == heredoc.delimiter {
+				break
+			}
+		}
+	}
+	p.heredocs = nil
+}
+
+// writeByte adds a byte to a word's value, counting newlines
+func (p *shellParser) writeByte(value *strings.Builder, ch byte) {
+	if ch == '\n' {
+		p.line++
+	}
+	value.WriteByte(ch)
+}
+
+// shellName returns the variable name a string starts with
+func shellName(s string) string {
+	if s == "" || !isShellNameChar(s[0]) || (s[0] >= '0' && s[0] <= '9') {
+		return ""
+	}
+	end := 1
+	for end < len(s) && isShellNameChar(s[end]) {
+		end++
+	}
+	return s[:end]
+}
+
+// isShellNameChar checks if a byte can be part of a variable name
+func isShellNameChar(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
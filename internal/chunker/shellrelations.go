@@ -0,0 +1,269 @@
+package chunker
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+)
+
+// shellBuiltins are the builtins and keywords that can't be calls to functions
+var shellBuiltins = map[string]bool{
+	":": true, ".": true, "[": true, "alias": true, "bg": true, "break": true, "builtin": true,
+	"caller": true, "cd": true, "command": true, "compgen": true, "complete": true, "continue": true,
+	"declare": true, "dirs": true, "disown": true, "echo": true, "enable": true, "eval": true,
+	"exec": true, "exit": true, "export": true, "false": true, "fg": true, "getopts": true,
+	"hash": true, "help": true, "history": true, "jobs": true, "kill": true, "let": true,
+	"local": true, "logout": true, "mapfile": true, "popd": true, "printf": true, "pushd": true,
+	"pwd": true, "read": true, "readarray": true, "readonly": true, "return": true, "set": true,
+	"shift": true, "shopt": true, "source": true, "suspend": true, "test": true, "times": true,
+	"trap": true, "true": true, "type": true, "typeset": true, "ulimit": true, "umask": true,
+	"unalias": true, "unset": true, "wait": true,
+}
+
+var (
+	// shellAssignment matches a variable assignment, NAME=value, NAME+=value or NAME[i]=value
+	shellAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(\[[^\]]*\])?\+?=`)
+
+	// shellEnvName matches the names of environment variables, which are
+	// upper case by convention; lower case names are the script's own
+	shellEnvName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+	// shellFunctionName matches the names a function can be called by
+	shellFunctionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_:.-]*$`)
+)
+
+// addRelations records the relations of a script's chunks to other files:
+// the scripts it sources, resolved relative to it, become imports of every
+// chunk, and file references of the chunk sourcing them; commands that may
+// be functions become references; and the environment variables it
+// exports or assigns are defined as env:NAME, with their expansions as
+// references
+func (c *ShellChunker) addRelations(filePath string, commands []*shellCommand, chunks []model.Chunk, symbolTable *model.SymbolTable) {
+	chunkAt := func(line int) *model.Chunk {
+		for i := range chunks {
+			if line >= chunks[i].StartLine && line <= chunks[i].EndLine {
+				return &chunks[i]
+			}
+		}
+		return nil
+	}
+	reference := func(symbol string, line int) {
+		if chunk := chunkAt(line); chunk != nil {
+			symbolTable.AddReference(symbol, model.SymbolReference{
+				Name:     symbol,
+				ChunkID:  chunk.ID,
+				FilePath: chunk.FilePath,
+				Line:     line,
+			})
+		}
+	}
+	define := func(name string, line int) {
+		if chunk := chunkAt(line); chunk != nil {
+			symbol := envSymbol(name)
+			symbolTable.AddDefinition(symbol, model.SymbolDefinition{
+				Name:      symbol,
+				ChunkID:   chunk.ID,
+				FilePath:  chunk.FilePath,
+				StartLine: chunk.StartLine,
+				EndLine:   chunk.EndLine,
+				Type:      "env",
+			})
+		}
+	}
+
+	scriptDirs := shellScriptDirs(commands)
+
+	var imports []string
+	var walk func(commands []*shellCommand, inFunction bool)
+	walk = func(commands []*shellCommand, inFunction bool) {
+		for _, command := range commands {
+			for _, name := range command.vars {
+				if shellEnvName.MatchString(name) {
+					reference(envSymbol(name), command.startLine)
+				}
+			}
+			walk(command.body, inFunction || command.function != "")
+
+			// Assignments before a command only set its environment
+			words := command.words
+			for len(words) > 0 && shellAssignment.MatchString(words[0].text) {
+				words = words[1:]
+			}
+			if len(command.words) > 0 && len(words) == 0 {
+				for _, word := range command.words {
+					if name := shellAssignment.FindStringSubmatch(word.text)[1]; shellEnvName.MatchString(name) {
+						define(name, word.line)
+					}
+				}
+			}
+			if len(words) == 0 || !words[0].literal {
+				continue
+			}
+
+			name, args := words[0].value, words[1:]
+			switch name {
+			case "source", ".":
+				if len(args) == 0 {
+					continue
+				}
+				if path, ok := shellSourcePath(filePath, args[0], scriptDirs); ok {
+					if !slices.Contains(imports, path) {
+						imports = append(imports, path)
+					}
+					if chunk := chunkAt(args[0].line); chunk != nil {
+						symbolTable.AddFileReference(path, model.SymbolReference{
+							Name:     path,
+							ChunkID:  chunk.ID,
+							FilePath: chunk.FilePath,
+							Line:     args[0].line,
+						})
+					}
+				}
+			case "export", "declare", "typeset", "readonly":
+				for _, word := range shellDeclaredVariables(name, args, inFunction) {
+					define(word.value, word.line)
+				}
+			default:
+				if !shellBuiltins[name] && shellFunctionName.MatchString(name) {
+					reference(name, words[0].line)
+				}
+			}
+		}
+	}
+	walk(commands, false)
+
+	for i := range chunks {
+		chunks[i].Imports = imports
+	}
+}
+
+// shellDeclaredVariables returns the environment variables set by export,
+// declare, typeset or readonly, with the values dropped. Every exported
+// variable is one, while other declarations only set upper case names,
+// and declarations in functions are local unless declared global
+func shellDeclaredVariables(builtin string, args []shellWord, inFunction bool) []shellWord {
+	exported := builtin == "export"
+	global := !inFunction || builtin == "export"
+
+	var names []shellWord
+	for _, arg := range args {
+		if strings.HasPrefix(arg.value, "-") || strings.HasPrefix(arg.value, "+") {
+			exported = exported || (arg.value[0] == '-' && strings.Contains(arg.value, "x"))
+			global = global || (arg.value[0] == '-' && strings.Contains(arg.value, "g"))
+			continue
+		}
+		name, _, _ := strings.Cut(arg.value, "=")
+		name = strings.TrimSuffix(name, "+")
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			name = name[:i]
+		}
+		if shellName(name) != name {
+			continue
+		}
+		if exported || (global && shellEnvName.MatchString(name)) {
+			names = append(names, shellWord{value: name, line: arg.line})
+		}
+	}
+	return names
+}
+
+// shellSourcePath resolves the script sourced by source or ., relative to
+// the sourcing script. A leading expansion is only resolved when it is the
+// script's own directory: $(dirname "$0"), ${BASH_SOURCE%/*}, ${0%/*} or a
+// variable the script assigned from one of them. Paths depending on any
+// other expansion, such as $HOME, can't be resolved
+func shellSourcePath(filePath string, word shellWord, scriptDirs map[string]bool) (string, bool) {
+	path := word.value
+	if strings.HasPrefix(path, "$") {
+		end := shellExpansionEnd(path)
+		if end <= 1 || end >= len(path) || path[end] != '/' || !shellIsScriptDir(path[:end], scriptDirs) {
+			return "", false
+		}
+		path = path[end+1:]
+	}
+	if path == "" || strings.ContainsAny(path, "$`") {
+		return "", false
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), true
+	}
+	return filepath.Join(filepath.Dir(filePath), path), true
+}
+
+// shellExpansionEnd returns the end of the expansion a word starts with
+func shellExpansionEnd(word string) int {
+	switch {
+	case strings.HasPrefix(word, "${"):
+		return shellClosing(word, 2, '{', '}') + 1
+	case strings.HasPrefix(word, "$("):
+		return shellClosing(word, 2, '(', ')') + 1
+	default:
+		return 1 + len(shellName(word[1:]))
+	}
+}
+
+// shellScriptPath matches the script's own path, $0 or $BASH_SOURCE
+const shellScriptPath = `"?(?:\$0|\$\{0\}|\$BASH_SOURCE|\$\{BASH_SOURCE(?:\[0\])?\})"?`
+
+var (
+	// shellDirnameSubst matches a command substitution of the script's
+	// directory, $(dirname "$0"), $(dirname "${BASH_SOURCE[0]}") or
+	// $(cd "$(dirname "$0")" && pwd)
+	shellDirnameSubst = regexp.MustCompile(`^\$\(\s*(?:dirname\s+` + shellScriptPath +
+		`|cd\s+"?\$\(\s*dirname\s+` + shellScriptPath + `\s*\)"?\s*(?:&&|;)\s*pwd(?:\s+-P)?)\s*\)$`)
+
+	// shellDirnameTrim matches the script's path with its name removed,
+	// ${BASH_SOURCE%/*}, ${BASH_SOURCE[0]%/*} or ${0%/*}
+	shellDirnameTrim = regexp.MustCompile(`^\$\{(?:BASH_SOURCE(?:\[0\])?|0)%/\*\}$`)
+)
+
+// shellIsScriptDir checks if an expansion is the script's own directory,
+// either computed from its path or held by one of scriptDirs
+func shellIsScriptDir(expansion string, scriptDirs map[string]bool) bool {
+	if shellDirnameSubst.MatchString(expansion) || shellDirnameTrim.MatchString(expansion) {
+		return true
+	}
+	name := strings.TrimPrefix(expansion, "$")
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+		name = name[1 : len(name)-1]
+	}
+	return shellName(name) == name && scriptDirs[name]
+}
+
+// shellScriptDirs returns the variables assigned the script's own directory,
+// such as SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+func shellScriptDirs(commands []*shellCommand) map[string]bool {
+	scriptDirs := make(map[string]bool)
+	var walk func(commands []*shellCommand)
+	walk = func(commands []*shellCommand) {
+		for _, command := range commands {
+			walk(command.body)
+
+			words := command.words
+			if len(words) > 0 && words[0].literal {
+				switch words[0].value {
+				case "local", "export", "declare", "typeset", "readonly":
+					words = words[1:]
+				}
+			}
+			for _, word := range words {
+				if strings.HasPrefix(word.value, "-") {
+					continue
+				}
+				match := shellAssignment.FindStringSubmatch(word.value)
+				if match == nil {
+					break
+				}
+				value := word.value[len(match[0]):]
+				if match[2] == "" && strings.HasPrefix(value, "$") && shellExpansionEnd(value) == len(value) && shellIsScriptDir(value, scriptDirs) {
+					scriptDirs[match[1]] = true
+				}
+			}
+		}
+	}
+	walk(commands)
+	return scriptDirs
+}
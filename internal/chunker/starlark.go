@@ -0,0 +1,459 @@
+package chunker
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// StarlarkChunker implements the Chunker interface for Bazel BUILD, WORKSPACE and .bzl files
+type StarlarkChunker struct {
+	// Bazel packages by directory
+	packages map[string]string
+}
+
+// NewStarlarkChunker creates a new Starlark chunker
+func NewStarlarkChunker() *StarlarkChunker {
+	return &StarlarkChunker{}
+}
+
+// starlarkStatement is a top-level statement, including the comments above it
+type starlarkStatement struct {
+	startLine int // 1-based, first line including leading comments
+	codeLine  int // 1-based, first line of the statement itself
+	endLine   int // 1-based, last non-blank line
+	code      string
+}
+
+// Statement patterns
+var (
+	starlarkDefPattern    = regexp.MustCompile(`^def\s+(\w+)\s*\(`)
+	starlarkCallPattern   = regexp.MustCompile(`^([A-Za-z_][\w.]*)\s*\(`)
+	starlarkAssignPattern = regexp.MustCompile(`^([A-Za-z_]\w*)\s*=[^=]`)
+	starlarkIdentPattern  = regexp.MustCompile(`^\w+$`)
+	starlarkStringPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'`)
+)
+
+// starlarkDepAttributes lists the rule attributes whose labels are dependencies
+var starlarkDepAttributes = []string{"deps", "runtime_deps", "implementation_deps", "exports", "plugins"}
+
+// Language returns the language this chunker supports
+func (c *StarlarkChunker) Language() string {
+	return "starlark"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *StarlarkChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "starlark"
+}
+
+// Chunk splits a Starlark file into one chunk per rule invocation and function definition
+func (c *StarlarkChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	lines := strings.Split(string(content), "\n")
+	statements := splitStarlarkStatements(lines)
+	pkg := c.bazelPackage(filePath, options.RootDir)
+	workspaceFile := isBazelWorkspaceFile(filePath)
+
+	// load() statements are file-level imports, shared by every chunk
+	var imports []string
+	for _, stmt := range statements {
+		if match := starlarkCallPattern.FindStringSubmatch(stmt.code); match != nil && match[1] == "load" {
+			if labels := starlarkStrings(stmt.code); len(labels) > 0 {
+				imports = append(imports, normalizeBazelLabel(labels[0], pkg))
+			}
+		}
+	}
+
+	var chunks []model.Chunk
+	var pending []starlarkStatement
+
+	// flushPending emits the statements that aren't rules or functions, grouped up to MaxChunkSize
+	flushPending := func() {
+		for len(pending) > 0 {
+			n := 1
+			for n < len(pending) && pending[n].endLine-pending[0].startLine+1 <= options.MaxChunkSize {
+				n++
+			}
+			chunks = append(chunks, c.createOtherChunk(filePath, lines, pending[:n], imports, symbolTable))
+			pending = pending[n:]
+		}
+	}
+
+	for _, stmt := range statements {
+		if match := starlarkDefPattern.FindStringSubmatch(stmt.code); match != nil {
+			flushPending()
+			chunk := c.createChunk(filePath, lines, stmt, []string{match[1]}, imports)
+			c.addDefinition(symbolTable, chunk, match[1], "function")
+			chunks = append(chunks, chunk)
+			continue
+		}
+
+		match := starlarkCallPattern.FindStringSubmatch(stmt.code)
+		if match == nil || match[1] == "load" {
+			pending = append(pending, stmt)
+			continue
+		}
+
+		kwargs := starlarkKeywordArgs(stmt.code)
+		names := starlarkStrings(kwargs["name"])
+		if len(names) == 0 {
+			pending = append(pending, stmt)
+			continue
+		}
+
+		label := "//" + pkg + ":" + names[0]
+		if workspaceFile {
+			label = "@" + names[0]
+		}
+
+		flushPending()
+		chunk := c.createChunk(filePath, lines, stmt, []string{label}, imports)
+		c.addDefinition(symbolTable, chunk, label, "rule")
+
+		// The rule or macro being invoked may be defined in a .bzl file
+		c.addReference(symbolTable, chunk, match[1], stmt.codeLine)
+
+		for _, attr := range starlarkDepAttributes {
+			for _, dep := range starlarkStrings(kwargs[attr]) {
+				c.addReference(symbolTable, chunk, normalizeBazelLabel(dep, pkg), stmt.codeLine)
+			}
+		}
+
+		chunks = append(chunks, chunk)
+	}
+	flushPending()
+
+	return chunks, nil
+}
+
+// createChunk creates a chunk for a single statement
+func (c *StarlarkChunker) createChunk(filePath string, lines []string, stmt starlarkStatement, symbols []string, imports []string) model.Chunk {
+	content := strings.Join(lines[stmt.startLine-1:stmt.endLine], "\n")
+
+	return model.Chunk{
+		ID:         util.GenerateID(filePath, content),
+		FilePath:   filePath,
+		StartLine:  stmt.startLine,
+		EndLine:    stmt.endLine,
+		Content:    content,
+		Language:   "starlark",
+		Symbols:    symbols,
+		Imports:    imports,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+}
+
+// createOtherChunk creates a chunk for a run of loads, assignments and unnamed calls
+func (c *StarlarkChunker) createOtherChunk(filePath string, lines []string, stmts []starlarkStatement, imports []string, symbolTable *model.SymbolTable) model.Chunk {
+	var symbols []string
+	for _, stmt := range stmts {
+		if match := starlarkAssignPattern.FindStringSubmatch(stmt.code); match != nil {
+			symbols = append(symbols, match[1])
+		}
+	}
+
+	chunk := c.createChunk(filePath, lines, starlarkStatement{
+		startLine: stmts[0].startLine,
+		endLine:   stmts[len(stmts)-1].endLine,
+	}, symbols, imports)
+
+	for _, symbol := range symbols {
+		c.addDefinition(symbolTable, chunk, symbol, "var")
+	}
+
+	return chunk
+}
+
+// addDefinition records a symbol defined by a chunk
+func (c *StarlarkChunker) addDefinition(symbolTable *model.SymbolTable, chunk model.Chunk, name string, defType string) {
+	symbolTable.AddDefinition(name, model.SymbolDefinition{
+		Name:      name,
+		ChunkID:   chunk.ID,
+		FilePath:  chunk.FilePath,
+		StartLine: chunk.StartLine,
+		EndLine:   chunk.EndLine,
+		Type:      defType,
+	})
+}
+
+// addReference records a symbol used by a chunk
+func (c *StarlarkChunker) addReference(symbolTable *model.SymbolTable, chunk model.Chunk, name string, line int) {
+	symbolTable.AddReference(name, model.SymbolReference{
+		Name:     name,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+	})
+}
+
+// splitStarlarkStatements splits a file into top-level statements. A statement
+// starts on an unindented line outside any bracket or string, and owns the
+// comments and blank lines that precede it
+func splitStarlarkStatements(lines []string) []starlarkStatement {
+	var starts []int
+	depth := 0
+	quote := ""
+	continued := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if depth == 0 && quote == "" && !continued && trimmed != "" &&
+			!strings.HasPrefix(trimmed, "#") && line[0] != ' ' && line[0] != '\t' {
+			starts = append(starts, i)
+		}
+		depth, quote, continued = scanStarlarkLine(line, depth, quote)
+	}
+
+	var statements []starlarkStatement
+	prevEnd := -1
+	for n, start := range starts {
+		next := len(lines)
+		if n+1 < len(starts) {
+			next = starts[n+1]
+		}
+
+		// The statement ends at its last line of code; unindented comments after it belong to the next one
+		end := next - 1
+		for end > start && isStarlarkTrailingLine(lines[end]) {
+			end--
+		}
+		if n+1 == len(starts) {
+			// Comments at the end of the file have no statement to precede
+			for last := len(lines) - 1; last > end; last-- {
+				if strings.TrimSpace(lines[last]) != "" {
+					end = last
+					break
+				}
+			}
+		}
+
+		// Leading comments start after the previous statement and any blank lines
+		first := prevEnd + 1
+		for first < start && strings.TrimSpace(lines[first]) == "" {
+			first++
+		}
+
+		statements = append(statements, starlarkStatement{
+			startLine: first + 1,
+			codeLine:  start + 1,
+			endLine:   end + 1,
+			code:      strings.Join(lines[start:end+1], "\n"),
+		})
+		prevEnd = end
+	}
+
+	return statements
+}
+
+// scanStarlarkLine advances the bracket depth and string state over one line
+func scanStarlarkLine(line string, depth int, quote string) (int, string, bool) {
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+
+		if quote != "" {
+			switch {
+			case ch == '\\':
+				i++
+			case strings.HasPrefix(line[i:], quote):
+				i += len(quote) - 1
+				quote = ""
+			}
+			continue
+		}
+
+		switch ch {
+		case '#':
+			return depth, quote, false
+		case '"', '\'':
+			quote = string(ch)
+			if strings.HasPrefix(line[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+				i += 2
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+
+	// Single-quoted strings can't span lines
+	if len(quote) == 1 {
+		quote = ""
+	}
+
+	return depth, quote, strings.HasSuffix(line, "\\")
+}
+
+// starlarkKeywordArgs returns the raw value text of each keyword argument of a call
+func starlarkKeywordArgs(code string) map[string]string {
+	kwargs := make(map[string]string)
+	depth := 0
+	quote := ""
+	argStart := -1
+
+	flush := func(end int) {
+		if argStart < 0 {
+			return
+		}
+		arg := code[argStart:end]
+		if eq := strings.Index(arg, "="); eq > 0 && !strings.HasPrefix(arg[eq:], "==") {
+			name := strings.TrimSpace(arg[:eq])
+			if starlarkIdentPattern.MatchString(name) {
+				kwargs[name] = strings.TrimSpace(arg[eq+1:])
+			}
+		}
+		argStart = -1
+	}
+
+	for i := 0; i < len(code); i++ {
+		ch := code[i]
+
+		if quote != "" {
+			switch {
+			case ch == '\\':
+				i++
+			case strings.HasPrefix(code[i:], quote):
+				i += len(quote) - 1
+				quote = ""
+			}
+			continue
+		}
+
+		switch ch {
+		case '#':
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			quote = string(ch)
+			if strings.HasPrefix(code[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+				i += 2
+			}
+		case '(', '[', '{':
+			depth++
+			if depth == 1 {
+				argStart = i + 1
+			}
+		case ')', ']', '}':
+			if depth == 1 {
+				flush(i)
+			}
+			depth--
+		case ',':
+			if depth == 1 {
+				flush(i)
+				argStart = i + 1
+			}
+		}
+	}
+
+	return kwargs
+}
+
+// starlarkStrings returns the string literals in an expression, skipping select() conditions
+func starlarkStrings(expr string) []string {
+	var values []string
+	for _, match := range starlarkStringPattern.FindAllStringSubmatch(expr, -1) {
+		value := match[1] + match[2]
+		if strings.HasPrefix(value, "//conditions:") {
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// isStarlarkTrailingLine checks if a line is blank or an unindented comment
+func isStarlarkTrailingLine(line string) bool {
+	return strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#")
+}
+
+// normalizeBazelLabel expands a label relative to pkg into its canonical //pkg:target form
+func normalizeBazelLabel(label string, pkg string) string {
+	repo := ""
+	if strings.HasPrefix(label, "@") {
+		idx := strings.Index(label, "//")
+		if idx < 0 {
+			// @repo is shorthand for @repo//:repo
+			return label + "//:" + strings.TrimPrefix(label, "@")
+		}
+		repo, label = label[:idx], label[idx:]
+	}
+
+	switch {
+	case strings.HasPrefix(label, "//"):
+		if !strings.Contains(label, ":") {
+			label += ":" + filepath.Base(label)
+		}
+	case strings.HasPrefix(label, ":"):
+		label = "//" + pkg + label
+	default:
+		label = "//" + pkg + ":" + label
+	}
+
+	return repo + label
+}
+
+// bazelPackage computes the Bazel package of a file: its directory relative to
+// the enclosing workspace, or to rootDir when no workspace marker is found.
+// The workspace is only looked for up to rootDir, and packages are cached
+// by directory
+func (c *StarlarkChunker) bazelPackage(filePath string, rootDir string) string {
+	dir := filepath.Dir(filePath)
+	if pkg, ok := c.packages[dir]; ok {
+		return pkg
+	}
+	if c.packages == nil {
+		c.packages = make(map[string]string)
+	}
+
+	root := rootDir
+	for candidate := dir; ; candidate = filepath.Dir(candidate) {
+		if hasBazelWorkspaceFile(candidate) {
+			root = candidate
+			break
+		}
+		if rel, err := filepath.Rel(rootDir, candidate); rootDir == "" || err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			break
+		}
+	}
+
+	pkg := ""
+	if rel, err := filepath.Rel(root, dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		pkg = filepath.ToSlash(rel)
+	}
+	c.packages[dir] = pkg
+	return pkg
+}
+
+// bazelWorkspaceFiles are the files that mark the root of a Bazel workspace
+var bazelWorkspaceFiles = []string{"WORKSPACE", "WORKSPACE.bazel", "WORKSPACE.bzlmod", "MODULE.bazel"}
+
+// hasBazelWorkspaceFile checks if a directory is the root of a Bazel workspace
+func hasBazelWorkspaceFile(dir string) bool {
+	for _, name := range bazelWorkspaceFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isBazelWorkspaceFile checks if a file declares external repositories rather than targets
+func isBazelWorkspaceFile(filePath string) bool {
+	base := filepath.Base(filePath)
+	for _, name := range bazelWorkspaceFiles {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
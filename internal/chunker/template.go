@@ -0,0 +1,579 @@
+package chunker
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// TemplateChunker implements the Chunker interface for Go templates and Helm charts
+type TemplateChunker struct {
+	// charts finds the chart of each file within the chunking root
+	charts util.HelmCharts
+}
+
+// NewTemplateChunker creates a new Go template chunker
+func NewTemplateChunker() *TemplateChunker {
+	return &TemplateChunker{}
+}
+
+// templateAction is a {{ ... }} action, with its body stripped of delimiters and trim markers
+type templateAction struct {
+	startOffset int
+	endOffset   int
+	startLine   int // 1-based
+	endLine     int
+	body        string
+}
+
+// templateRegion is a {{ define }} block, including the comments directly above it
+type templateRegion struct {
+	name      string
+	startLine int
+	endLine   int
+}
+
+// yamlKey is a mapping key that can be addressed as .Values.a.b
+type yamlKey struct {
+	line   int // 1-based
+	indent int
+	path   string
+}
+
+// Template patterns
+var (
+	templateKeywordPattern = regexp.MustCompile(`^(\w+)`)
+	templateCallPattern    = regexp.MustCompile(`\b(template|include|block)\s+"([^"]+)"`)
+	templateValuesPattern  = regexp.MustCompile(`\.Values((?:\.\w+)+)`)
+	templateIndexPattern   = regexp.MustCompile(`\bindex\s+\$?\.Values((?:\s+"[^"]+")+)`)
+	templateQuotedPattern  = regexp.MustCompile(`"([^"]+)"`)
+	helmChartNamePattern   = regexp.MustCompile(`(?m)^name:\s*["']?([^"'\s#]+)`)
+)
+
+// Language returns the language this chunker supports
+func (c *TemplateChunker) Language() string {
+	return "gotemplate"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *TemplateChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "gotemplate" || framework == "helm"
+}
+
+// Chunk splits a template into one chunk per {{ define }} block and per YAML
+// document, or a chart's values.yaml into one chunk per top-level key
+func (c *TemplateChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	lines := strings.Split(string(content), "\n")
+
+	// .Values keys are namespaced by chart so that charts in one tree don't link to each other
+	chart := ""
+	c.charts.RootDir = options.RootDir
+	if c.charts.IsChartFile(filePath) {
+		chart = helmChartName(c.charts.ChartRoot(filePath))
+	}
+
+	if chart != "" && filepath.Base(filePath) == "values.yaml" {
+		return c.chunkValues(filePath, lines, chart, symbolTable, options), nil
+	}
+
+	text := string(content)
+	actions := scanTemplateActions(text)
+	regions := findTemplateRegions(text, actions)
+
+	// Lines inside a multi-line action can't start a document or a split
+	insideAction := make([]bool, len(lines)+2)
+	for _, action := range actions {
+		for line := action.startLine + 1; line <= action.endLine; line++ {
+			insideAction[line] = true
+		}
+	}
+
+	var chunks []model.Chunk
+	emit := func(startLine, endLine int, symbols []string) {
+		for startLine < endLine && strings.TrimSpace(lines[startLine-1]) == "" {
+			startLine++
+		}
+		for endLine > startLine && strings.TrimSpace(lines[endLine-1]) == "" {
+			endLine--
+		}
+		if strings.TrimSpace(strings.Join(lines[startLine-1:endLine], "")) == "" {
+			return
+		}
+		chunks = append(chunks, c.createChunk(filePath, lines, startLine, endLine, symbols, chart, actions, symbolTable))
+	}
+
+	regionIdx := 0
+	for line := 1; line <= len(lines); {
+		if regionIdx < len(regions) && regions[regionIdx].startLine == line {
+			region := regions[regionIdx]
+			emit(region.startLine, region.endLine, []string{region.name})
+			regionIdx++
+			line = region.endLine + 1
+			continue
+		}
+
+		// A document runs up to the next --- separator or define block
+		end := line
+		for end+1 <= len(lines) &&
+			(regionIdx >= len(regions) || end+1 < regions[regionIdx].startLine) &&
+			(insideAction[end+1] || strings.TrimRight(lines[end], " \t") != "---") {
+			end++
+		}
+
+		c.splitDocument(lines, line, end, insideAction, options, emit)
+		line = end + 1
+	}
+
+	return chunks, nil
+}
+
+// splitDocument emits a document whole, or split before top-level lines if it exceeds MaxChunkSize
+func (c *TemplateChunker) splitDocument(lines []string, startLine, endLine int, insideAction []bool, options ChunkingOptions, emit func(startLine, endLine int, symbols []string)) {
+	section := configSection{startLine: startLine, endLine: endLine}
+	for line := startLine; line <= endLine; line++ {
+		text := lines[line-1]
+		if text != "" && text[0] != ' ' && text[0] != '\t' && !insideAction[line] {
+			section.entries = append(section.entries, line)
+		}
+	}
+
+	if endLine-startLine+1 <= options.MaxChunkSize {
+		emit(startLine, endLine, nil)
+		return
+	}
+	splitSection(section, options, func(_ []configSection, start, end int) {
+		emit(start, end, nil)
+	})
+}
+
+// chunkValues splits a chart's values.yaml at its top-level keys and defines every key path
+func (c *TemplateChunker) chunkValues(filePath string, lines []string, chart string, symbolTable *model.SymbolTable, options ChunkingOptions) []model.Chunk {
+	keys := parseYAMLKeys(lines)
+
+	// Each top-level key owns the comments directly above it, and oversized keys are split between their children
+	var sections []configSection
+	current := configSection{startLine: 1}
+	childIndent := 0
+	for _, key := range keys {
+		if key.indent > 0 {
+			if childIndent == 0 {
+				childIndent = key.indent
+			}
+			if key.indent == childIndent {
+				current.entries = append(current.entries, key.line)
+			}
+			continue
+		}
+
+		start := key.line
+		for start > current.startLine && strings.HasPrefix(strings.TrimSpace(lines[start-2]), "#") {
+			start--
+		}
+		if start > current.startLine {
+			current.endLine = start - 1
+			current.entries = trimConfigEntries(current.entries, current.endLine)
+			sections = append(sections, current)
+		}
+		current = configSection{path: key.path, startLine: start}
+		childIndent = 0
+	}
+
+	end := len(lines)
+	for end > current.startLine && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	current.endLine = end
+	if current.path != "" || strings.TrimSpace(strings.Join(lines[current.startLine-1:end], "")) != "" {
+		sections = append(sections, current)
+	}
+
+	var chunks []model.Chunk
+	packSections(sections, options, func(_ []configSection, startLine, endLine int) {
+		content := strings.Join(lines[startLine-1:endLine], "\n")
+
+		var symbols []string
+		for _, key := range keys {
+			if key.line >= startLine && key.line <= endLine {
+				symbols = append(symbols, helmValueSymbol(chart, key.path))
+			}
+		}
+
+		chunk := model.Chunk{
+			ID:         util.GenerateID(filePath, content),
+			FilePath:   filePath,
+			StartLine:  startLine,
+			EndLine:    endLine,
+			Content:    content,
+			Language:   "yaml",
+			Framework:  "helm",
+			Symbols:    symbols,
+			TokenCount: util.EstimateTokenCount(content),
+		}
+		for _, symbol := range symbols {
+			c.addDefinition(symbolTable, chunk, symbol, "value")
+		}
+		chunks = append(chunks, chunk)
+	})
+
+	return chunks
+}
+
+// createChunk creates a chunk for a range of template lines, recording the
+// templates it calls and the values it reads
+func (c *TemplateChunker) createChunk(filePath string, lines []string, startLine, endLine int, symbols []string, chart string, actions []templateAction, symbolTable *model.SymbolTable) model.Chunk {
+	content := strings.Join(lines[startLine-1:endLine], "\n")
+
+	// block both defines a template and calls it
+	for _, action := range actions {
+		if action.startLine < startLine || action.startLine > endLine {
+			continue
+		}
+		for _, match := range templateCallPattern.FindAllStringSubmatch(action.body, -1) {
+			if match[1] == "block" {
+				symbols = append(symbols, match[2])
+			}
+		}
+	}
+
+	chunk := model.Chunk{
+		ID:         util.GenerateID(filePath, content),
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   templateLanguage(filePath),
+		Symbols:    symbols,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+	if chart != "" {
+		chunk.Framework = "helm"
+	}
+
+	for _, symbol := range symbols {
+		c.addDefinition(symbolTable, chunk, symbol, "template")
+	}
+
+	for _, action := range actions {
+		if action.startLine < startLine || action.startLine > endLine {
+			continue
+		}
+		for _, match := range templateCallPattern.FindAllStringSubmatch(action.body, -1) {
+			if match[1] != "block" {
+				c.addReference(symbolTable, chunk, match[2], action.startLine)
+			}
+		}
+		if chart == "" {
+			continue
+		}
+		for _, match := range templateValuesPattern.FindAllStringSubmatch(action.body, -1) {
+			c.addReference(symbolTable, chunk, helmValueSymbol(chart, match[1][1:]), action.startLine)
+		}
+		for _, match := range templateIndexPattern.FindAllStringSubmatch(action.body, -1) {
+			var path []string
+			for _, key := range templateQuotedPattern.FindAllStringSubmatch(match[1], -1) {
+				path = append(path, key[1])
+			}
+			c.addReference(symbolTable, chunk, helmValueSymbol(chart, strings.Join(path, ".")), action.startLine)
+		}
+	}
+
+	return chunk
+}
+
+// addDefinition records a symbol defined by a chunk
+func (c *TemplateChunker) addDefinition(symbolTable *model.SymbolTable, chunk model.Chunk, name string, defType string) {
+	symbolTable.AddDefinition(name, model.SymbolDefinition{
+		Name:      name,
+		ChunkID:   chunk.ID,
+		FilePath:  chunk.FilePath,
+		StartLine: chunk.StartLine,
+		EndLine:   chunk.EndLine,
+		Type:      defType,
+	})
+}
+
+// addReference records a symbol used by a chunk
+func (c *TemplateChunker) addReference(symbolTable *model.SymbolTable, chunk model.Chunk, name string, line int) {
+	symbolTable.AddReference(name, model.SymbolReference{
+		Name:     name,
+		ChunkID:  chunk.ID,
+		FilePath: chunk.FilePath,
+		Line:     line,
+	})
+}
+
+// scanTemplateActions finds the {{ ... }} actions in a template. Delimiters
+// inside strings and comments don't end an action
+func scanTemplateActions(text string) []templateAction {
+	var actions []templateAction
+	line := 1
+	lineOffset := 0
+
+	lineAt := func(offset int) int {
+		line += strings.Count(text[lineOffset:offset], "\n")
+		lineOffset = offset
+		return line
+	}
+
+	for pos := 0; pos < len(text); {
+		open := strings.Index(text[pos:], "{{")
+		if open < 0 {
+			break
+		}
+		start := pos + open
+		end := templateActionEnd(text, start+2)
+		if end < 0 {
+			// An unterminated action leaves the rest of the file as text
+			break
+		}
+
+		body := text[start+2 : end-2]
+		if strings.HasPrefix(body, "- ") || strings.HasPrefix(body, "-\n") {
+			body = body[1:]
+		}
+		if strings.HasSuffix(body, " -") || strings.HasSuffix(body, "\n-") {
+			body = body[:len(body)-1]
+		}
+
+		actions = append(actions, templateAction{
+			startOffset: start,
+			endOffset:   end,
+			startLine:   lineAt(start),
+			endLine:     lineAt(end),
+			body:        strings.TrimSpace(body),
+		})
+		pos = end
+	}
+
+	return actions
+}
+
+// templateActionEnd returns the offset just past the }} closing an action, or -1
+func templateActionEnd(text string, pos int) int {
+	for i := pos; i < len(text); i++ {
+		switch {
+		case text[i] == '"' || text[i] == '\'':
+			// Interpreted strings and rune literals can't span lines
+			quote := text[i]
+			for i++; i < len(text) && text[i] != quote && text[i] != '\n'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+		case text[i] == '`':
+			if end := strings.IndexByte(text[i+1:], '`'); end >= 0 {
+				i += end + 1
+			}
+		case strings.HasPrefix(text[i:], "/*"):
+			if end := strings.Index(text[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			}
+		case strings.HasPrefix(text[i:], "}}"):
+			return i + 2
+		}
+	}
+	return -1
+}
+
+// findTemplateRegions finds the top-level {{ define }} blocks, extended over
+// standalone comment actions directly above them
+func findTemplateRegions(text string, actions []templateAction) []templateRegion {
+	var regions []templateRegion
+	var stack []string
+	var comments []templateAction
+	current := templateRegion{}
+
+	for _, action := range actions {
+		keyword := ""
+		if match := templateKeywordPattern.FindStringSubmatch(action.body); match != nil {
+			keyword = match[1]
+		}
+
+		switch keyword {
+		case "define", "block", "if", "range", "with":
+			if keyword == "define" && len(stack) == 0 {
+				current = templateRegion{startLine: action.startLine}
+				if match := templateQuotedPattern.FindStringSubmatch(action.body); match != nil {
+					current.name = match[1]
+				}
+			}
+			stack = append(stack, keyword)
+		case "end":
+			if len(stack) == 0 {
+				continue
+			}
+			if len(stack) == 1 && stack[0] == "define" && current.name != "" {
+				current.endLine = action.endLine
+				regions = append(regions, current)
+			}
+			stack = stack[:len(stack)-1]
+		case "":
+			if strings.HasPrefix(action.body, "/*") && isStandaloneAction(text, action) {
+				comments = append(comments, action)
+			}
+		}
+	}
+
+	// Attach comment blocks like "{{/* Expand the name of the chart. */}}" to the define below them
+	for i := range regions {
+		floor := 1
+		if i > 0 {
+			floor = regions[i-1].endLine + 1
+		}
+		for j := len(comments) - 1; j >= 0; j-- {
+			comment := comments[j]
+			if comment.endLine == regions[i].startLine-1 && comment.startLine >= floor {
+				regions[i].startLine = comment.startLine
+			}
+		}
+	}
+
+	return regions
+}
+
+// isStandaloneAction checks if an action is alone on its lines
+func isStandaloneAction(text string, action templateAction) bool {
+	lineStart := strings.LastIndexByte(text[:action.startOffset], '\n') + 1
+	lineEnd := strings.IndexByte(text[action.endOffset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(text) - action.endOffset
+	}
+	return strings.TrimSpace(text[lineStart:action.startOffset]) == "" &&
+		strings.TrimSpace(text[action.endOffset:action.endOffset+lineEnd]) == ""
+}
+
+// parseYAMLKeys returns the mapping keys of a YAML document that are reachable
+// through nested mappings alone. Keys inside lists and block scalars are skipped
+func parseYAMLKeys(lines []string) []yamlKey {
+	type frame struct {
+		indent int
+		path   string
+		list   bool
+	}
+
+	var keys []yamlKey
+	var stack []frame
+	blockIndent := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		rest := line[indent:]
+		if rest == "-" || strings.HasPrefix(rest, "- ") {
+			// A list item closes its siblings but not the key holding the list
+			for len(stack) > 0 && (stack[len(stack)-1].indent > indent ||
+				(stack[len(stack)-1].indent == indent && stack[len(stack)-1].list)) {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, frame{indent: indent, list: true})
+
+			item := strings.TrimLeft(strings.TrimPrefix(rest, "-"), " ")
+			indent += len(rest) - len(item)
+			rest = item
+		} else {
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		key, value, ok := yamlKeyValue(rest)
+		if !ok {
+			if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+				blockIndent = indent - 1
+			}
+			continue
+		}
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+
+		path := key
+		addressable := true
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			path = parent.path + "." + key
+			addressable = !parent.list && parent.path != ""
+		}
+		if !addressable {
+			path = ""
+		}
+		stack = append(stack, frame{indent: indent, path: path})
+
+		if path != "" {
+			keys = append(keys, yamlKey{line: i + 1, indent: indent, path: path})
+		}
+	}
+
+	return keys
+}
+
+// yamlKeyValue splits a "key: value" line, returning false if it isn't a mapping entry
+func yamlKeyValue(s string) (string, string, bool) {
+	if s == "" {
+		return "", "", false
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 {
+			return "", "", false
+		}
+		rest := strings.TrimLeft(s[end+2:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return s[1 : end+1], strings.TrimSpace(rest[1:]), true
+	}
+
+	colon := strings.Index(s, ": ")
+	if colon < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", false
+		}
+		colon = len(s) - 1
+	}
+
+	key := strings.TrimSpace(s[:colon])
+	if key == "" || strings.ContainsAny(key[:1], "#&*!|>?{[%@`") || strings.Contains(key, " #") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(s[colon+1:]), true
+}
+
+// helmValueSymbol names a values key as chart:.Values.path
+func helmValueSymbol(chart string, path string) string {
+	return chart + ":.Values." + path
+}
+
+// helmChartName reads the chart name from Chart.yaml, falling back to the directory name
+func helmChartName(chartRoot string) string {
+	if content, err := os.ReadFile(filepath.Join(chartRoot, "Chart.yaml")); err == nil {
+		if match := helmChartNamePattern.FindSubmatch(content); match != nil {
+			return string(match[1])
+		}
+	}
+	return filepath.Base(chartRoot)
+}
+
+// templateLanguage determines whether a template renders YAML from the file extension
+func templateLanguage(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "gotemplate"
+}
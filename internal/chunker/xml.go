@@ -0,0 +1,377 @@
+package chunker
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/stream-ai/chunk/internal/model"
+	"github.com/stream-ai/chunk/pkg/util"
+)
+
+// DefaultXMLSplitPaths are the element paths the XML chunker splits on when none are configured.
+// A path matches an element whose ancestors end with it, so "ItemGroup" matches any ItemGroup
+// while "project/dependencies/dependency" only matches direct dependencies of a Maven project
+var DefaultXMLSplitPaths = []string{
+	// Maven
+	"project/dependencies/dependency",
+	"project/dependencyManagement",
+	"project/build/plugins/plugin",
+	"project/profiles/profile",
+
+	// MSBuild (.csproj, .props, .targets)
+	"ItemGroup",
+	"PropertyGroup",
+	"Project/Target",
+
+	// Android manifests
+	"manifest/uses-permission",
+	"manifest/application/activity",
+	"manifest/application/service",
+	"manifest/application/receiver",
+	"manifest/application/provider",
+}
+
+// XMLChunker implements the Chunker interface for XML files such as build descriptors and manifests
+type XMLChunker struct {
+	// SplitPaths are the element paths that each get their own chunk
+	SplitPaths []string
+}
+
+// NewXMLChunker creates a new XML chunker splitting on the given element paths,
+// or on DefaultXMLSplitPaths if none are given
+func NewXMLChunker(splitPaths []string) *XMLChunker {
+	if len(splitPaths) == 0 {
+		splitPaths = DefaultXMLSplitPaths
+	}
+	return &XMLChunker{
+		SplitPaths: splitPaths,
+	}
+}
+
+// xmlFrame is an open element while decoding
+type xmlFrame struct {
+	name       string
+	path       string // XPath-like path of the element
+	start      int64
+	childCount map[string]int
+	split      bool
+}
+
+// xmlRegion is an element that gets its own chunk
+type xmlRegion struct {
+	path      string
+	startLine int
+	endLine   int
+}
+
+// xmlCoordinate is a dependency declared in a well-known descriptor
+type xmlCoordinate struct {
+	line  int
+	value string
+}
+
+// Language returns the language this chunker supports
+func (c *XMLChunker) Language() string {
+	return "xml"
+}
+
+// CanHandle checks if this chunker can handle the given file
+func (c *XMLChunker) CanHandle(filePath string, language string, framework string) bool {
+	return language == "xml"
+}
+
+// Chunk splits an XML file on the configured element paths. Content outside
+// the matched elements is chunked by lines under the path of its enclosing element
+func (c *XMLChunker) Chunk(filePath string, content []byte, symbolTable *model.SymbolTable, options ChunkingOptions) ([]model.Chunk, error) {
+	regions, linePaths, coordinates, err := c.findRegions(filePath, content)
+	if err != nil {
+		// Malformed XML is still worth indexing as text
+		return NewGenericChunker().Chunk(filePath, content, symbolTable, options)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	covered := make([]bool, len(lines)+1)
+	for _, region := range regions {
+		for line := region.startLine; line <= region.endLine; line++ {
+			covered[line] = true
+		}
+	}
+
+	var chunks []model.Chunk
+	regionIdx := 0
+
+	for line := 1; line <= len(lines); {
+		if covered[line] {
+			// Emit the regions starting on this line
+			for regionIdx < len(regions) && regions[regionIdx].startLine <= line {
+				region := regions[regionIdx]
+				imports := xmlCoordinatesInRange(coordinates, region.startLine, region.endLine)
+				chunks = append(chunks, c.createChunk(filePath, lines, region.startLine, region.endLine, region.path, imports, symbolTable))
+				regionIdx++
+			}
+			line++
+			continue
+		}
+
+		// Emit the uncovered run of lines in windows of MaxChunkSize
+		end := line
+		for end+1 <= len(lines) && !covered[end+1] && end-line+1 < options.MaxChunkSize {
+			end++
+		}
+		if strings.TrimSpace(strings.Join(lines[line-1:end], "")) != "" {
+			// Closing tags open no element, so fall back to the last path seen before the run
+			path := ""
+			for l := line; l <= end && path == ""; l++ {
+				path = linePaths[l]
+			}
+			for l := line - 1; l > 0 && path == ""; l-- {
+				path = linePaths[l]
+			}
+			imports := xmlCoordinatesInRange(coordinates, line, end)
+			chunks = append(chunks, c.createChunk(filePath, lines, line, end, path, imports, symbolTable))
+		}
+		line = end + 1
+	}
+
+	return chunks, nil
+}
+
+// findRegions decodes the document and returns the elements matching the split
+// paths, the path of the first element opened on each other line and the
+// dependency coordinates of well-known descriptors
+func (c *XMLChunker) findRegions(filePath string, content []byte) ([]xmlRegion, map[int]string, []xmlCoordinate, error) {
+	var regions []xmlRegion
+	var coordinates []xmlCoordinate
+	linePaths := make(map[int]string)
+	descriptor := xmlDescriptorKind(filePath)
+
+	lineAt := func(offset int64) int {
+		return bytes.Count(content[:offset], []byte("\n")) + 1
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	dec.Strict = false
+
+	root := &xmlFrame{childCount: make(map[string]int)}
+	stack := []*xmlFrame{root}
+	splitDepth := 0 // depth of the open split element, 0 if none
+
+	// Maven declares coordinates in child elements, collected until the dependency closes
+	var dependencyFields map[string]string
+	dependencyLine := 0
+
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := stack[len(stack)-1]
+			name := t.Name.Local
+			parent.childCount[name]++
+
+			step := name
+			if index := parent.childCount[name]; index > 1 {
+				step = fmt.Sprintf("%s[%d]", name, index)
+			}
+			frame := &xmlFrame{
+				name:       name,
+				path:       parent.path + "/" + step,
+				start:      offset,
+				childCount: make(map[string]int),
+			}
+			stack = append(stack, frame)
+
+			line := lineAt(offset)
+			if splitDepth == 0 && c.matches(stack) {
+				frame.split = true
+				splitDepth = len(stack)
+				// The symbol always carries the element's position among its siblings
+				frame.path = fmt.Sprintf("%s/%s[%d]", parent.path, name, parent.childCount[name])
+			} else if _, ok := linePaths[line]; !ok && splitDepth == 0 {
+				linePaths[line] = frame.path
+			}
+
+			if coordinate := xmlAttributeCoordinate(descriptor, t); coordinate != "" {
+				coordinates = append(coordinates, xmlCoordinate{line: line, value: coordinate})
+			}
+			if descriptor == "maven" && name == "dependency" {
+				dependencyFields = make(map[string]string)
+				dependencyLine = line
+			}
+
+		case xml.CharData:
+			// Only the dependency's own fields, not those of its exclusions
+			if dependencyFields != nil && len(stack) > 1 && stack[len(stack)-2].name == "dependency" {
+				field := stack[len(stack)-1].name
+				if field == "groupId" || field == "artifactId" || field == "version" {
+					dependencyFields[field] += strings.TrimSpace(string(t))
+				}
+			}
+
+		case xml.EndElement:
+			// Pop up to the matching element; non-strict documents may leave elements unclosed
+			for len(stack) > 1 {
+				frame := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				if frame.name == "dependency" && dependencyFields != nil {
+					coordinates = append(coordinates, xmlCoordinate{line: dependencyLine, value: mavenCoordinate(dependencyFields)})
+					dependencyFields = nil
+				}
+
+				if frame.split {
+					regions = append(regions, xmlRegion{
+						path:      frame.path,
+						startLine: lineAt(frame.start),
+						endLine:   lineAt(dec.InputOffset()),
+					})
+					splitDepth = 0
+				}
+
+				if frame.name == t.Name.Local {
+					break
+				}
+			}
+		}
+	}
+
+	return regions, linePaths, coordinates, nil
+}
+
+// matches checks if the open element stack ends with one of the split paths
+func (c *XMLChunker) matches(stack []*xmlFrame) bool {
+	for _, splitPath := range c.SplitPaths {
+		anchored := strings.HasPrefix(splitPath, "/")
+		segments := strings.Split(strings.Trim(splitPath, "/"), "/")
+
+		// The first frame is the document itself
+		elements := stack[1:]
+		if len(segments) > len(elements) || (anchored && len(segments) != len(elements)) {
+			continue
+		}
+
+		tail := elements[len(elements)-len(segments):]
+		matched := true
+		for i, segment := range segments {
+			if tail[i].name != segment {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// createChunk creates a chunk from a range of lines
+func (c *XMLChunker) createChunk(filePath string, lines []string, startLine, endLine int, path string, imports []string, symbolTable *model.SymbolTable) model.Chunk {
+	content := strings.Join(lines[startLine-1:endLine], "\n")
+	chunkID := util.GenerateID(filePath, content)
+
+	var symbols []string
+	if path != "" {
+		symbols = []string{path}
+		symbolTable.AddDefinition(path, model.SymbolDefinition{
+			Name:      path,
+			ChunkID:   chunkID,
+			FilePath:  filePath,
+			StartLine: startLine,
+			EndLine:   endLine,
+			Type:      "element",
+		})
+	}
+
+	return model.Chunk{
+		ID:         chunkID,
+		FilePath:   filePath,
+		StartLine:  startLine,
+		EndLine:    endLine,
+		Content:    content,
+		Language:   "xml",
+		Symbols:    symbols,
+		Imports:    imports,
+		TokenCount: util.EstimateTokenCount(content),
+	}
+}
+
+// xmlCoordinatesInRange returns the coordinates declared within a range of lines
+func xmlCoordinatesInRange(coordinates []xmlCoordinate, startLine, endLine int) []string {
+	var values []string
+	for _, coordinate := range coordinates {
+		if coordinate.line >= startLine && coordinate.line <= endLine {
+			values = append(values, coordinate.value)
+		}
+	}
+	return values
+}
+
+// xmlDescriptorKind identifies the well-known descriptors whose dependencies we extract
+func xmlDescriptorKind(filePath string) string {
+	base := filepath.Base(filePath)
+	switch {
+	case base == "pom.xml":
+		return "maven"
+	case base == "AndroidManifest.xml":
+		return "android"
+	}
+
+	switch filepath.Ext(base) {
+	case ".csproj", ".fsproj", ".vbproj", ".props", ".targets":
+		return "msbuild"
+	}
+	return ""
+}
+
+// xmlAttributeCoordinate extracts a dependency declared in an element's attributes
+func xmlAttributeCoordinate(descriptor string, element xml.StartElement) string {
+	attr := func(name string) string {
+		for _, a := range element.Attr {
+			if a.Name.Local == name {
+				return a.Value
+			}
+		}
+		return ""
+	}
+
+	switch {
+	case descriptor == "msbuild" && element.Name.Local == "PackageReference":
+		name := attr("Include")
+		if name == "" {
+			name = attr("Update")
+		}
+		if version := attr("Version"); version != "" && name != "" {
+			return name + "@" + version
+		}
+		return name
+
+	case descriptor == "msbuild" && element.Name.Local == "ProjectReference":
+		return filepath.ToSlash(strings.ReplaceAll(attr("Include"), "\\", "/"))
+
+	case descriptor == "android" && element.Name.Local == "uses-library":
+		return attr("name")
+	}
+
+	return ""
+}
+
+// mavenCoordinate formats Maven dependency fields as groupId:artifactId[:version]
+func mavenCoordinate(fields map[string]string) string {
+	coordinate := fields["groupId"] + ":" + fields["artifactId"]
+	if version := fields["version"]; version != "" {
+		coordinate += ":" + version
+	}
+	return coordinate
+}
@@ -2,13 +2,24 @@ package detector
 
 import (
 	"path/filepath"
+	"regexp"
 	"strings"
+
+	"github.com/stream-ai/chunk/pkg/util"
 )
 
+// composeFilePattern matches docker-compose.yml, compose.yaml and overrides such as docker-compose.prod.yml
+var composeFilePattern = regexp.MustCompile(`^(docker-)?compose(\.[\w-]+)*\.ya?ml$`)
+
 // DefaultFrameworkDetector implements the FrameworkDetector interface
 type DefaultFrameworkDetector struct {
 	// frameworkPatterns maps frameworks to byte patterns to look for in the content
 	frameworkPatterns map[string][]string
+
+	// RootDir is the root of the tree being processed, above which no Helm
+	// chart is looked for
+	RootDir string
+	charts  util.HelmCharts
 }
 
 // NewDefaultFrameworkDetector creates a new DefaultFrameworkDetector
@@ -106,6 +117,18 @@ func (d *DefaultFrameworkDetector) DetectFramework(filePath string, content []by
 		return "svelte"
 	}
 
+	// Helm charts are recognised by their Chart.yaml and compose files by their name
+	if language == "yaml" || language == "gotemplate" {
+		d.charts.RootDir = d.RootDir
+		if d.charts.IsChartFile(filePath) {
+			return "helm"
+		}
+		if language == "yaml" && composeFilePattern.MatchString(strings.ToLower(filepath.Base(filePath))) {
+			return "compose"
+		}
+		return ""
+	}
+
 	// No need to check for frameworks in non-JS/TS files
 	if language != "javascript" && language != "typescript" && language != "jsx" && language != "tsx" && language != "dart" {
 		return ""
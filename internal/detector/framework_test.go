@@ -0,0 +1,45 @@
+// internal/detector/framework_test.go
+
+package detector_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stream-ai/chunk/internal/detector"
+)
+
+// TestDetectInfrastructureFrameworks tests that compose files are recognised
+// by their name and Helm chart files by their chart
+func TestDetectInfrastructureFrameworks(t *testing.T) {
+	chart := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chart, "Chart.yaml"), []byte("name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	frameworkDetector := detector.NewDefaultFrameworkDetector()
+	frameworkDetector.RootDir = chart
+
+	tests := []struct {
+		path     string
+		language string
+		expected string
+	}{
+		{"docker-compose.yml", "yaml", "compose"},
+		{"deploy/compose.yaml", "yaml", "compose"},
+		{"docker-compose.prod.yml", "yaml", "compose"},
+		{"Docker-Compose.override.yaml", "yaml", "compose"},
+		{"compose-notes.yml", "yaml", ""},
+		{"config/app.yml", "yaml", ""},
+		{filepath.Join(chart, "values.yaml"), "yaml", "helm"},
+		{filepath.Join(chart, "templates", "deployment.yaml"), "yaml", "helm"},
+		{filepath.Join(chart, "templates", "_helpers.tpl"), "gotemplate", "helm"},
+		{filepath.Join(chart, "ci", "values.yaml"), "yaml", ""},
+	}
+
+	for _, test := range tests {
+		if framework := frameworkDetector.DetectFramework(test.path, []byte("key: value\n"), test.language); framework != test.expected {
+			t.Errorf("DetectFramework(%q) = %q, expected %q", test.path, framework, test.expected)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package detector
 import (
 	"bytes"
 	"path/filepath"
+	"regexp"
 	"strings"
+
+	"github.com/stream-ai/chunk/pkg/util"
 )
 
 // DefaultLanguageDetector implements the LanguageDetector interface
@@ -13,6 +16,11 @@ type DefaultLanguageDetector struct {
 
 	// specialFilesMap maps specific filenames to languages
 	specialFilesMap map[string]string
+
+	// RootDir is the root of the tree being processed, above which no Helm
+	// chart is looked for
+	RootDir string
+	charts  util.HelmCharts
 }
 
 // NewDefaultLanguageDetector creates a new DefaultLanguageDetector
@@ -53,6 +61,13 @@ func (d *DefaultLanguageDetector) registerExtensions() {
 	d.extensionMap[".rs"] = "rust"
 	d.extensionMap[".php"] = "php"
 	d.extensionMap[".dart"] = "dart"
+	d.extensionMap[".ipynb"] = "jupyter"
+	d.extensionMap[".bzl"] = "starlark"
+	d.extensionMap[".bazel"] = "starlark"
+	d.extensionMap[".star"] = "starlark"
+	d.extensionMap[".cmake"] = "cmake"
+	d.extensionMap[".tmpl"] = "gotemplate"
+	d.extensionMap[".gotmpl"] = "gotemplate"
 
 	// Shell scripts and config files
 	d.extensionMap[".sh"] = "shell"
@@ -73,11 +88,20 @@ func (d *DefaultLanguageDetector) registerExtensions() {
 
 	// Data/config formats
 	d.extensionMap[".json"] = "json"
+	d.extensionMap[".xml"] = "xml"
+	d.extensionMap[".csproj"] = "xml"
+	d.extensionMap[".fsproj"] = "xml"
+	d.extensionMap[".vbproj"] = "xml"
+	d.extensionMap[".props"] = "xml"
+	d.extensionMap[".targets"] = "xml"
 	d.extensionMap[".yaml"] = "yaml"
 	d.extensionMap[".yml"] = "yaml"
 	d.extensionMap[".toml"] = "toml"
 	d.extensionMap[".md"] = "markdown"
 	d.extensionMap[".markdown"] = "markdown"
+	d.extensionMap[".rst"] = "rst"
+	d.extensionMap[".adoc"] = "asciidoc"
+	d.extensionMap[".asciidoc"] = "asciidoc"
 	d.extensionMap[".ini"] = "ini"
 	d.extensionMap[".conf"] = "conf"
 	d.extensionMap[".env"] = "env"
@@ -130,16 +154,35 @@ func (d *DefaultLanguageDetector) DetectLanguage(filePath string, content []byte
 		return "shell"
 	}
 
-	// Check extension mappings
-	if language, ok := d.extensionMap[ext]; ok {
-		return language
+	// Bazel BUILD and WORKSPACE files have no extension either. They are matched
+	// exactly so that e.g. build.gradle is not mistaken for Starlark, and after
+	// the shebang so that a build script named build stays a script
+	if baseName == "build" || baseName == "workspace" {
+		return "starlark"
 	}
 
-	// Check special filenames
+	// Check special filenames before extensions, so that e.g. CMakeLists.txt isn't plain text
 	if language, ok := d.specialFilesMap[baseName]; ok {
 		return language
 	}
 
+	// Plain text is only prose when it documents something, other .txt files are often data
+	if ext == ".txt" && isDocumentationText(filePath) {
+		return "text"
+	}
+
+	// Many template languages use .tpl, so it's only a Go template in a Helm
+	// chart or when it has Go template actions
+	d.charts.RootDir = d.RootDir
+	if ext == ".tpl" && (goTemplateAction.Match(content) || d.charts.IsChartFile(filePath)) {
+		return "gotemplate"
+	}
+
+	// Check extension mappings
+	if language, ok := d.extensionMap[ext]; ok {
+		return language
+	}
+
 	// Check for special filenames with extensions
 	for specialFile, language := range d.specialFilesMap {
 		if strings.HasPrefix(baseName, specialFile+".") {
@@ -150,3 +193,47 @@ func (d *DefaultLanguageDetector) DetectLanguage(filePath string, content []byte
 	// Default fallback
 	return "unknown"
 }
+
+// goTemplateAction matches the actions only Go templates have, such as
+// {{ define "name" }} or {{ .Values.image }}
+var goTemplateAction = regexp.MustCompile(`\{\{-?\s*(?:define|template|block)\s+"|\{\{-?\s*[.$][A-Za-z_]`)
+
+// documentationNames are the names of plain text files holding documentation
+var documentationNames = []string{
+	"readme", "changelog", "changes", "history", "news", "notes", "release-notes", "release_notes",
+	"contributing", "authors", "install", "upgrading", "faq", "usage", "guide",
+}
+
+// documentationDirs are the directories whose plain text files are documentation
+var documentationDirs = map[string]bool{"doc": true, "docs": true, "documentation": true, "manual": true}
+
+// dataTextNames are the names of plain text files that are data or legal
+// text, even in a documentation directory
+var dataTextNames = []string{"requirements", "constraints", "robots", "license", "licence", "copying", "notice"}
+
+// isDocumentationText checks if a .txt file is documentation, from its name
+// (README.txt, CHANGELOG.txt, ...) or from being in a documentation directory
+func isDocumentationText(filePath string) bool {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+	hasName := func(names []string) bool {
+		for _, candidate := range names {
+			if name == candidate || strings.HasPrefix(name, candidate+".") || strings.HasPrefix(name, candidate+"-") || strings.HasPrefix(name, candidate+"_") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasName(dataTextNames) {
+		return false
+	}
+	if hasName(documentationNames) {
+		return true
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(filePath)), "/") {
+		if documentationDirs[strings.ToLower(dir)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// internal/detector/language_test.go
+
+package detector_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stream-ai/chunk/internal/detector"
+)
+
+// TestDetectBazelFiles tests that BUILD and WORKSPACE files are Starlark
+// unless they are scripts
+func TestDetectBazelFiles(t *testing.T) {
+	languageDetector := detector.NewDefaultLanguageDetector()
+
+	tests := []struct {
+		path     string
+		content  string
+		expected string
+	}{
+		{"pkg/BUILD", "load(\"@rules_go//go:def.bzl\", \"go_library\")\n", "starlark"},
+		{"pkg/BUILD.bazel", "go_library(name = \"pkg\")\n", "starlark"},
+		{"WORKSPACE", "workspace(name = \"repo\")\n", "starlark"},
+		{"defs.bzl", "def macro():\n    pass\n", "starlark"},
+		{"scripts/build", "#!/bin/bash\nset -e\ngo build ./...\n", "shell"},
+		{"workspace", "#!/usr/bin/env python3\nprint('setup')\n", "python"},
+		{"build.gradle", "plugins { id 'java' }\n", "unknown"},
+	}
+
+	for _, test := range tests {
+		if language := languageDetector.DetectLanguage(test.path, []byte(test.content)); language != test.expected {
+			t.Errorf("DetectLanguage(%q) = %q, expected %q", test.path, language, test.expected)
+		}
+	}
+}
+
+// TestDetectPlainText tests that only documentation .txt files are prose
+func TestDetectPlainText(t *testing.T) {
+	languageDetector := detector.NewDefaultLanguageDetector()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"README.txt", "text"},
+		{"CHANGELOG.txt", "text"},
+		{"release-notes-1.2.txt", "text"},
+		{"docs/design/overview.txt", "text"},
+		{"requirements.txt", "unknown"},
+		{"docs/requirements.txt", "unknown"},
+		{"requirements-dev.txt", "unknown"},
+		{"static/robots.txt", "unknown"},
+		{"LICENSE.txt", "unknown"},
+		{"testdata/expected_output.txt", "unknown"},
+		{"src/CMakeLists.txt", "cmake"},
+	}
+
+	for _, test := range tests {
+		if language := languageDetector.DetectLanguage(test.path, []byte("Some text.\n")); language != test.expected {
+			t.Errorf("DetectLanguage(%q) = %q, expected %q", test.path, language, test.expected)
+		}
+	}
+}
+
+// TestDetectTplTemplates tests that .tpl files are only Go templates in Helm
+// charts or when they have Go template actions
+func TestDetectTplTemplates(t *testing.T) {
+	chart := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chart, "Chart.yaml"), []byte("name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	languageDetector := detector.NewDefaultLanguageDetector()
+	languageDetector.RootDir = chart
+
+	tests := []struct {
+		path     string
+		content  string
+		expected string
+	}{
+		{filepath.Join(chart, "templates", "_helpers.tpl"), "{{/* Common labels */}}\n", "gotemplate"},
+		{"config/app.tpl", "{{ define \"app.name\" }}app{{ end }}\n", "gotemplate"},
+		{"config/app.tpl", "name: {{- .Name }}\n", "gotemplate"},
+		{"themes/index.tpl", "<h1>{$title}</h1>\n{if $user}Hi{/if}\n", "unknown"},
+		{"views/page.tpl", "<p>{{name}}</p>\n{{#items}}<li>{{.}}</li>{{/items}}\n", "unknown"},
+		{"mail/welcome.tmpl", "Hello {{ name }}\n", "gotemplate"},
+	}
+
+	for _, test := range tests {
+		if language := languageDetector.DetectLanguage(test.path, []byte(test.content)); language != test.expected {
+			t.Errorf("DetectLanguage(%q) = %q, expected %q", test.path, language, test.expected)
+		}
+	}
+
+	// A chart above the root being processed doesn't count
+	languageDetector.RootDir = filepath.Join(chart, "templates")
+	path := filepath.Join(chart, "templates", "_helpers.tpl")
+	if language := languageDetector.DetectLanguage(path, []byte("{{/* Common labels */}}\n")); language != "unknown" {
+		t.Errorf("DetectLanguage(%q) = %q, expected %q with the root below the chart", path, language, "unknown")
+	}
+}
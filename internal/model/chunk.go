@@ -13,9 +13,72 @@ type Chunk struct {
 	Imports       []string `json:"imports,omitempty"`
 	RelatedChunks []string `json:"related_chunks,omitempty"`
 	TokenCount    int      `json:"token_count,omitempty"`
+
+	// Calls and CalledBy are the IDs of the chunks of the functions this
+	// chunk calls and of the functions calling it
+	Calls    []string `json:"calls,omitempty"`
+	CalledBy []string `json:"called_by,omitempty"`
+
+	// Messages are the error and log messages the chunk's code produces
+	Messages []Message `json:"messages,omitempty"`
+
+	// Command describes the command-line command a chunk documents
+	Command *Command `json:"command,omitempty"`
+
+	// Metadata holds language-specific facts about the chunk, such as Go build constraints
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Kind classifies chunks beyond their language, such as Go tests, benchmarks, examples and fuzz targets
+	Kind string `json:"kind,omitempty"`
+
+	// ParentID links a part of a split declaration to the chunk holding its first part
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// Message is an error or log message produced by code, as a template with
+// MessageWildcard standing for formatted values, and the lines of the calls
+// producing it
+type Message struct {
+	Template string `json:"template"`
+	Lines    []int  `json:"lines"`
+}
+
+// MessageWildcard stands for a formatted value in message templates. It's the
+// NUL character, which doesn't occur in messages, so that a literal * in a
+// message isn't taken for a wildcard
+const MessageWildcard = "\x00"
+
+// Command is a command-line command defined in code, such as a cobra.Command
+// or the flags of a program
+type Command struct {
+	Name        string        `json:"name"`
+	Use         string        `json:"use,omitempty"`
+	Short       string        `json:"short,omitempty"`
+	Long        string        `json:"long,omitempty"`
+	Subcommands []string      `json:"subcommands,omitempty"`
+	Flags       []CommandFlag `json:"flags,omitempty"`
+}
+
+// CommandFlag is a flag of a command. Persistent flags also apply to subcommands
+type CommandFlag struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Default    string `json:"default,omitempty"`
+	Usage      string `json:"usage,omitempty"`
+	Persistent bool   `json:"persistent,omitempty"`
+}
+
+// Diagnostic reports a problem found in a file while chunking it, such as a syntax error
+type Diagnostic struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
 }
 
 // ChunkResult contains all chunks from processing
 type ChunkResult struct {
-	Chunks []Chunk `json:"chunks"`
+	Chunks      []Chunk      `json:"chunks"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 }
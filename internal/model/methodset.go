@@ -0,0 +1,216 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// methodSets holds what method sets are computed from
+type methodSets struct {
+	// methods maps a type's package directory and name to its method names
+	methods map[string]map[string]bool
+
+	// embeds maps a chunk ID and type name to the definitions each of the
+	// type's "embeds" references resolves to
+	embeds map[string][][]SymbolDefinition
+}
+
+// Implementations returns the chunks related to a chunk by interface
+// satisfaction: the interfaces its types implement, and the types
+// implementing its interfaces. Satisfaction is decided by method names, with
+// methods promoted through embedded types and interfaces. Chunkers that
+// resolve it precisely record "implements" references instead, and leave
+// the interface's Methods empty
+func (st *SymbolTable) Implementations(chunkID string) []string {
+	if st.implementations == nil {
+		st.implementations = st.computeImplementations()
+	}
+	return st.implementations[chunkID]
+}
+
+// computeImplementations matches every Go interface against the method set
+// of every Go type
+func (st *SymbolTable) computeImplementations() map[string][]string {
+	result := make(map[string][]string)
+
+	// Method names by package directory and receiver type
+	methods := make(map[string]map[string]bool)
+	var types, interfaces []SymbolDefinition
+
+	for symbol, defs := range st.Definitions {
+		for _, def := range defs {
+			if filepath.Ext(def.FilePath) != ".go" {
+				continue
+			}
+			switch def.Type {
+			case "type":
+				types = append(types, def)
+			case "interface":
+				interfaces = append(interfaces, def)
+			case "function":
+				typeName, method, ok := strings.Cut(symbol, ".")
+				if !ok {
+					continue
+				}
+				key := methodSetKey(def.FilePath, typeName)
+				if methods[key] == nil {
+					methods[key] = make(map[string]bool)
+				}
+				methods[key][method] = true
+			}
+		}
+	}
+
+	sets := &methodSets{methods: methods, embeds: st.embedsIndex()}
+
+	typeMethods := make([]map[string]bool, len(types))
+	for i, typ := range types {
+		typeMethods[i] = sets.typeMethods(typ, make(map[string]bool))
+	}
+
+	for _, iface := range interfaces {
+		required, complete := sets.interfaceMethods(iface, make(map[string]bool))
+		if !complete || len(required) == 0 {
+			continue
+		}
+
+		for i, typ := range types {
+			if containsAll(typeMethods[i], required) {
+				result[iface.ChunkID] = appendUnique(result[iface.ChunkID], typ.ChunkID)
+				result[typ.ChunkID] = appendUnique(result[typ.ChunkID], iface.ChunkID)
+			}
+		}
+	}
+
+	return result
+}
+
+// interfaceMethods returns the methods an interface requires, including
+// those of the interfaces it embeds. It is incomplete if an embedded
+// interface isn't known, e.g. one from an external module
+func (sets *methodSets) interfaceMethods(iface SymbolDefinition, visited map[string]bool) (map[string]bool, bool) {
+	required := make(map[string]bool)
+	if visited[iface.ChunkID+"|"+iface.Name] {
+		return required, true
+	}
+	visited[iface.ChunkID+"|"+iface.Name] = true
+
+	for _, method := range iface.Methods {
+		required[method] = true
+	}
+
+	complete := true
+	for _, embedded := range sets.embedded(iface) {
+		if len(embedded) == 0 {
+			complete = false
+			continue
+		}
+		found := false
+		for _, def := range embedded {
+			if def.Type != "interface" {
+				continue
+			}
+			found = true
+			methods, ok := sets.interfaceMethods(def, visited)
+			complete = complete && ok
+			for method := range methods {
+				required[method] = true
+			}
+		}
+		complete = complete && found
+	}
+
+	return required, complete
+}
+
+// typeMethods returns the method set of a type, including the methods
+// promoted from the types and interfaces it embeds
+func (sets *methodSets) typeMethods(typ SymbolDefinition, visited map[string]bool) map[string]bool {
+	methodSet := make(map[string]bool)
+	if visited[typ.ChunkID+"|"+typ.Name] {
+		return methodSet
+	}
+	visited[typ.ChunkID+"|"+typ.Name] = true
+
+	for method := range sets.methods[methodSetKey(typ.FilePath, typ.Name)] {
+		methodSet[method] = true
+	}
+
+	for _, embedded := range sets.embedded(typ) {
+		for _, def := range embedded {
+			var promoted map[string]bool
+			switch def.Type {
+			case "type":
+				promoted = sets.typeMethods(def, visited)
+			case "interface":
+				promoted, _ = sets.interfaceMethods(def, visited)
+			}
+			for method := range promoted {
+				methodSet[method] = true
+			}
+		}
+	}
+
+	return methodSet
+}
+
+// embedded returns the resolved "embeds" references of a type or interface.
+// References that don't name the embedding type belong to its whole chunk
+func (sets *methodSets) embedded(def SymbolDefinition) [][]SymbolDefinition {
+	embedded := sets.embeds[embedKey(def.ChunkID, def.Name)]
+	if def.Name != "" {
+		embedded = append(embedded, sets.embeds[embedKey(def.ChunkID, "")]...)
+	}
+	return embedded
+}
+
+// embedsIndex resolves the "embeds" references of every type
+func (st *SymbolTable) embedsIndex() map[string][][]SymbolDefinition {
+	embeds := make(map[string][][]SymbolDefinition)
+	for symbol, refs := range st.References {
+		for _, ref := range refs {
+			if ref.Kind != "embeds" {
+				continue
+			}
+			var defs []SymbolDefinition
+			for _, def := range st.Definitions[symbol] {
+				if (def.ChunkID != ref.ChunkID || def.Name != ref.Symbol) && ref.Resolves(def) {
+					defs = append(defs, def)
+				}
+			}
+			key := embedKey(ref.ChunkID, ref.Symbol)
+			embeds[key] = append(embeds[key], defs)
+		}
+	}
+	return embeds
+}
+
+// embedKey identifies a type by its chunk and name
+func embedKey(chunkID string, typeName string) string {
+	return chunkID + "|" + typeName
+}
+
+// methodSetKey identifies a type by its package directory and name
+func methodSetKey(filePath string, typeName string) string {
+	return filepath.Dir(filePath) + "|" + typeName
+}
+
+// containsAll checks if a set contains every element of another
+func containsAll(set map[string]bool, elements map[string]bool) bool {
+	for element := range elements {
+		if !set[element] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendUnique appends a string to a slice unless it's already there
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -28,6 +29,14 @@ type SymbolDefinition struct {
 	StartLine int
 	EndLine   int
 	Type      string // "function", "interface", "type", "const", "var", etc.
+
+	// Package is the import path of the package declaring the symbol, when
+	// it's known from the module the file belongs to
+	Package string
+
+	// Methods lists the methods an interface declares, for matching it
+	// against the method sets of types
+	Methods []string
 }
 
 // SymbolReference represents a usage of a symbol
@@ -36,6 +45,39 @@ type SymbolReference struct {
 	ChunkID  string
 	FilePath string
 	Line     int
+
+	// DefinitionFile and DefinitionLine locate the declaration the reference
+	// was resolved to by type checking. They are empty for name-based references
+	DefinitionFile string
+	DefinitionLine int
+
+	// Kind names references that are structural relations rather than uses:
+	// "embeds", "constraint" or "implements"
+	Kind string
+
+	// Package is the import path or directory of the package declaring the
+	// symbol, for references into packages that weren't type-checked
+	Package string
+	// Symbol is the symbol of the chunk the reference is made from, for
+	// chunks declaring several (e.g. a grouped type declaration). It's empty
+	// when the reference belongs to the chunk as a whole
+	Symbol string
+}
+
+// Resolves checks if a reference can refer to a definition. References that
+// were resolved only match the definition spanning their declaration, and
+// package-qualified references only match definitions in that package
+func (ref SymbolReference) Resolves(def SymbolDefinition) bool {
+	switch {
+	case ref.DefinitionFile != "":
+		return ref.DefinitionFile == def.FilePath &&
+			ref.DefinitionLine >= def.StartLine && ref.DefinitionLine <= def.EndLine
+	case ref.Package != "" && def.Package != "":
+		return ref.Package == def.Package
+	case ref.Package != "":
+		return extractPackageNameFromImport(ref.Package) == extractPackageName(def.FilePath)
+	}
+	return true
 }
 
 // SymbolTable holds all the detected symbols, their references, and chunks
@@ -44,6 +86,28 @@ type SymbolTable struct {
 	Definitions map[string][]SymbolDefinition
 	References  map[string][]SymbolReference
 	Chunks      map[string]Chunk // Map of chunk ID to chunk
+
+	// FileReferences maps a file path to the chunks that use the whole file,
+	// e.g. a compose service building a Dockerfile
+	FileReferences map[string][]SymbolReference
+
+	// Files maps a file path to the IDs of its chunks
+	Files map[string][]string
+
+	// Packages maps an import path to the directory of the package, for
+	// packages found in the tree
+	Packages map[string]string
+
+	// Diagnostics are the problems chunkers found in the files they processed
+	Diagnostics []Diagnostic
+
+	// implementations caches the interface satisfaction relations computed
+	// from method sets, and is reset whenever a symbol is added
+	implementations map[string][]string
+
+	// calls and calledBy cache the call graph, and are reset whenever a symbol is added
+	calls    map[string][]string
+	calledBy map[string][]string
 }
 
 // NewSymbolTable creates a new empty symbol table
@@ -52,21 +116,49 @@ func NewSymbolTable() *SymbolTable {
 		Definitions: make(map[string][]SymbolDefinition),
 		References:  make(map[string][]SymbolReference),
 		Chunks:      make(map[string]Chunk),
+
+		FileReferences: make(map[string][]SymbolReference),
+		Files:          make(map[string][]string),
+		Packages:       make(map[string]string),
 	}
 }
 
 // AddDefinition adds a symbol definition
 func (st *SymbolTable) AddDefinition(name string, def SymbolDefinition) {
 	st.Definitions[name] = append(st.Definitions[name], def)
+	st.implementations = nil
+	st.calls, st.calledBy = nil, nil
 }
 
 // AddReference adds a symbol reference
 func (st *SymbolTable) AddReference(name string, ref SymbolReference) {
 	st.References[name] = append(st.References[name], ref)
+	st.implementations = nil
+	st.calls, st.calledBy = nil, nil
+}
+
+// AddFileReference adds a reference to a whole file
+func (st *SymbolTable) AddFileReference(path string, ref SymbolReference) {
+	path = filepath.Clean(path)
+	st.FileReferences[path] = append(st.FileReferences[path], ref)
+}
+
+// AddPackage maps an import path to the directory of the package
+func (st *SymbolTable) AddPackage(importPath string, dir string) {
+	st.Packages[importPath] = filepath.Clean(dir)
+}
+
+// AddDiagnostic records a problem found in a file
+func (st *SymbolTable) AddDiagnostic(diagnostic Diagnostic) {
+	st.Diagnostics = append(st.Diagnostics, diagnostic)
 }
 
 // AddChunk adds a chunk to the symbol table
 func (st *SymbolTable) AddChunk(chunk Chunk) {
+	if _, exists := st.Chunks[chunk.ID]; !exists {
+		path := filepath.Clean(chunk.FilePath)
+		st.Files[path] = append(st.Files[path], chunk.ID)
+	}
 	st.Chunks[chunk.ID] = chunk
 }
 
@@ -100,6 +192,29 @@ func (st *SymbolTable) FindDefinitionsByType(defType string) []SymbolDefinition
 	return result
 }
 
+// CallGraph returns the IDs of the chunks of the functions a chunk calls, and
+// of the chunks calling the functions it defines, from "call" references
+func (st *SymbolTable) CallGraph(chunkID string) ([]string, []string) {
+	if st.calls == nil {
+		st.calls = make(map[string][]string)
+		st.calledBy = make(map[string][]string)
+		for symbol, refs := range st.References {
+			for _, ref := range refs {
+				if ref.Kind != "call" {
+					continue
+				}
+				for _, def := range st.Definitions[symbol] {
+					if def.Type == "function" && def.ChunkID != ref.ChunkID && ref.Resolves(def) {
+						st.calls[ref.ChunkID] = appendUnique(st.calls[ref.ChunkID], def.ChunkID)
+						st.calledBy[def.ChunkID] = appendUnique(st.calledBy[def.ChunkID], ref.ChunkID)
+					}
+				}
+			}
+		}
+	}
+	return st.calls[chunkID], st.calledBy[chunkID]
+}
+
 // FindRelatedChunks finds chunks related to the given chunk with proper semantic understanding
 func (st *SymbolTable) FindRelatedChunks(chunk Chunk) []string {
 	// Map of chunk IDs to their relation strength
@@ -111,36 +226,90 @@ func (st *SymbolTable) FindRelatedChunks(chunk Chunk) []string {
 	// 1. Find direct symbol reference relationships
 	// -----------------------------------------------
 
-	// Symbols defined in this chunk and referenced elsewhere
+	// Symbols defined in this chunk and referenced elsewhere. Chunkers that
+	// don't record definitions for their symbols are matched by location
 	for _, symbol := range chunk.Symbols {
-		for _, ref := range st.References[symbol] {
-			if ref.ChunkID != chunk.ID {
-				relatedChunks[ref.ChunkID] = max(relatedChunks[ref.ChunkID], RelationStrong)
+		var defs []SymbolDefinition
+		for _, def := range st.Definitions[symbol] {
+			if def.ChunkID == chunk.ID {
+				defs = append(defs, def)
+			}
+		}
+		if len(defs) == 0 {
+			defs = append(defs, SymbolDefinition{
+				FilePath:  chunk.FilePath,
+				StartLine: chunk.StartLine,
+				EndLine:   chunk.EndLine,
+				Package:   chunk.Metadata["package"],
+			})
+		}
+		for _, def := range defs {
+			for _, ref := range st.References[symbol] {
+				if ref.ChunkID != chunk.ID && ref.Resolves(def) {
+					relatedChunks[ref.ChunkID] = max(relatedChunks[ref.ChunkID], RelationStrong)
+				}
 			}
 		}
 	}
 
-	// Symbols referenced in this chunk but defined elsewhere
-	for symbol := range st.Definitions {
-		// Check if this symbol is referenced in the chunk
-		isReferenced := false
-		for _, ref := range st.References[symbol] {
-			if ref.ChunkID == chunk.ID {
-				isReferenced = true
-				break
+	// Symbols referenced in this chunk but defined elsewhere, and symbols this
+	// chunk defines without naming them, such as the configuration keys a
+	// struct decodes
+	for symbol, defs := range st.Definitions {
+		if !slices.Contains(chunk.Symbols, symbol) {
+			for _, def := range defs {
+				if def.ChunkID != chunk.ID {
+					continue
+				}
+				for _, ref := range st.References[symbol] {
+					if ref.ChunkID != chunk.ID && ref.Resolves(def) {
+						relatedChunks[ref.ChunkID] = max(relatedChunks[ref.ChunkID], RelationStrong)
+					}
+				}
 			}
 		}
 
-		if isReferenced {
-			// Find all definitions of this symbol
-			for _, def := range st.Definitions[symbol] {
-				if def.ChunkID != chunk.ID {
+		for _, ref := range st.References[symbol] {
+			if ref.ChunkID != chunk.ID {
+				continue
+			}
+			for _, def := range defs {
+				if def.ChunkID != chunk.ID && ref.Resolves(def) {
 					relatedChunks[def.ChunkID] = max(relatedChunks[def.ChunkID], RelationStrong)
 				}
 			}
 		}
 	}
 
+	// Files used by this chunk, and chunks using this chunk's file
+	for path, refs := range st.FileReferences {
+		for _, ref := range refs {
+			if ref.ChunkID != chunk.ID {
+				continue
+			}
+			for _, id := range st.Files[path] {
+				if id != chunk.ID {
+					relatedChunks[id] = max(relatedChunks[id], RelationStrong)
+				}
+			}
+		}
+	}
+	for _, ref := range st.FileReferences[filepath.Clean(chunk.FilePath)] {
+		if ref.ChunkID != chunk.ID {
+			relatedChunks[ref.ChunkID] = max(relatedChunks[ref.ChunkID], RelationStrong)
+		}
+	}
+
+	// Parts of a split declaration and the chunk holding its first part
+	if chunk.ParentID != "" {
+		relatedChunks[chunk.ParentID] = RelationStrong
+	}
+	for _, otherChunk := range st.AllChunks() {
+		if otherChunk.ParentID == chunk.ID {
+			relatedChunks[otherChunk.ID] = RelationStrong
+		}
+	}
+
 	// 2. Find method-type relationships (specific to Go)
 	// --------------------------------------------------
 
@@ -177,30 +346,11 @@ func (st *SymbolTable) FindRelatedChunks(chunk Chunk) []string {
 
 	// 3. Find interface-implementation relationships
 	// ---------------------------------------------
-	// This is a simplified approach without full type checking
 
-	// If this chunk defines an interface
-	for _, symbol := range chunk.Symbols {
-		// Look for definitions that seem to be interfaces (type name followed by "interface")
-		for _, def := range st.Definitions[symbol] {
-			if def.Type == "interface" && def.ChunkID == chunk.ID {
-				// Find potential implementers by looking for method name patterns
-				// For each chunk that contains method definitions...
-				for methodSymbol := range st.Definitions {
-					if strings.Contains(methodSymbol, ".") {
-						methodName := strings.Split(methodSymbol, ".")[1]
-
-						// Check if this method is mentioned in the interface
-						if strings.Contains(chunk.Content, methodName) {
-							for _, methodDef := range st.Definitions[methodSymbol] {
-								if methodDef.ChunkID != chunk.ID {
-									relatedChunks[methodDef.ChunkID] = max(relatedChunks[methodDef.ChunkID], RelationMedium)
-								}
-							}
-						}
-					}
-				}
-			}
+	// Interfaces this chunk's types satisfy, or the types satisfying its interfaces
+	for _, id := range st.Implementations(chunk.ID) {
+		if id != chunk.ID {
+			relatedChunks[id] = max(relatedChunks[id], RelationStrong)
 		}
 	}
 
@@ -208,6 +358,21 @@ func (st *SymbolTable) FindRelatedChunks(chunk Chunk) []string {
 	// ----------------------------
 
 	for _, imp := range chunk.Imports {
+		// Imports of packages in the tree relate to exactly that package
+		if dir, ok := st.Packages[imp]; ok {
+			for _, otherChunk := range st.AllChunks() {
+				if filepath.Dir(filepath.Clean(otherChunk.FilePath)) == dir && otherChunk.ID != chunk.ID {
+					relatedChunks[otherChunk.ID] = max(relatedChunks[otherChunk.ID], RelationMedium)
+				}
+			}
+			continue
+		}
+
+		// Chunks of a known package don't guess other imports by name
+		if chunk.Metadata["package"] != "" {
+			continue
+		}
+
 		// Get the package name from the import path
 		pkgName := extractPackageNameFromImport(imp)
 
@@ -281,7 +446,23 @@ func extractPackageName(filePath string) string {
 func extractPackageNameFromImport(importPath string) string {
 	// For import paths like "github.com/user/repo/pkg/subpkg",
 	// we want to extract "subpkg"
-	return filepath.Base(importPath)
+	base := filepath.Base(importPath)
+
+	// Major versions are not part of the name: github.com/user/repo/v2 is
+	// repo and gopkg.in/yaml.v3 is yaml
+	if isMajorVersion(base) && filepath.Dir(importPath) != "." {
+		base = filepath.Base(filepath.Dir(importPath))
+	}
+	if i := strings.LastIndex(base, "."); i > 0 && isMajorVersion(base[i+1:]) {
+		base = base[:i]
+	}
+	return base
+}
+
+// isMajorVersion checks if a path element is a major version such as v2
+func isMajorVersion(element string) bool {
+	digits, ok := strings.CutPrefix(element, "v")
+	return ok && digits != "" && strings.Trim(digits, "0123456789") == ""
 }
 
 // max returns the maximum of two RelationStrength values
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -59,3 +60,60 @@ func ShouldSkipDirectory(dirName string) bool {
 
 	return dirsToSkip[dirName]
 }
+
+// HelmCharts finds the Helm charts files belong to, from the Chart.yaml of
+// the nearest directory at or above the file's directory. The search doesn't
+// go above RootDir, and only looks at the file's own directory without one.
+// The chart of each directory is cached
+type HelmCharts struct {
+	RootDir string
+
+	// roots maps a directory to its chart's root, for rootDir
+	roots   map[string]string
+	rootDir string
+}
+
+// ChartRoot returns the root directory of the chart a file is in, or an
+// empty string if the file isn't in a Helm chart
+func (h *HelmCharts) ChartRoot(filePath string) string {
+	if h.roots == nil || h.rootDir != h.RootDir {
+		h.roots, h.rootDir = make(map[string]string), h.RootDir
+	}
+	return h.chartRoot(filepath.Dir(filePath))
+}
+
+// chartRoot returns the root of the chart a directory is in
+func (h *HelmCharts) chartRoot(dir string) string {
+	if root, ok := h.roots[dir]; ok {
+		return root
+	}
+
+	root := ""
+	if info, err := os.Stat(filepath.Join(dir, "Chart.yaml")); err == nil && !info.IsDir() {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir && isWithin(parent, h.RootDir) {
+		root = h.chartRoot(parent)
+	}
+	h.roots[dir] = root
+	return root
+}
+
+// IsChartFile checks if a file is a chart's values.yaml or lives under its templates directory
+func (h *HelmCharts) IsChartFile(filePath string) bool {
+	root := h.ChartRoot(filePath)
+	if root == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, filePath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	return rel == "values.yaml" || strings.HasPrefix(rel, "templates/")
+}
+
+// isWithin checks if a directory is a root directory or below it
+func isWithin(dir string, rootDir string) bool {
+	rel, err := filepath.Rel(rootDir, dir)
+	return rootDir != "" && err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}